@@ -1,71 +1,477 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/kelseyhightower/envconfig"
+	"catalogue-app/internal/pkg/log"
+	"catalogue-app/internal/pkg/secrets"
 )
 
 type Configuration struct {
 	DBConfig  DatabaseConfig
 	SvcConfig ServiceConfig
 	MailConf  MailConfig
+	AuthConf  AuthConfig
+	LogConf   LogConfig
+	AuditConf AuditConfig
+
+	// sources records where each setting came from, keyed by environment variable name.
+	sources map[string]string
+
+	// args and providers the configuration was loaded with, used by Reload.
+	args      []string
+	providers []secrets.Provider
 }
 
+// Settings are namespaced by component (DB_, HTTP_, MAIL_) so that keys such as
+// ports can't collide. The env tag names the environment variable, the YAML file
+// key and the command line flag are derived from it, see Load. Values of fields
+// tagged secret are redacted when the configuration is printed, fields tagged
+// reload can be changed at runtime without a restart, see Reload.
+
 type ServiceConfig struct {
-	LogLevel          string `envconfig:"LOG_LEVEL" default:"info"`
-	Port              uint   `envconfig:"PORT" default:"8080"`
-	ShutdownWait      uint16 `envConfig:"SHUTDOWN_WAIT" default:"20"`
-	HeaderReadTimeout uint16 `envConfig:"HEADER_READ_TIMEOUT" default:"20"`
-	GinAccessLog      bool   `envconfig:"GIN_ACCESS_LOG" default:"false"`
+	// DevMode allows starting with default credentials, never enable it in production.
+	DevMode bool `env:"DEV_MODE" default:"false"`
+
+	LogLevel     string        `env:"LOG_LEVEL" default:"info" reload:"true"`
+	Port         uint          `env:"HTTP_PORT" default:"8080"`
+	ShutdownWait time.Duration `env:"HTTP_SHUTDOWN_WAIT" default:"20s"`
+	// DrainDelay keeps serving after /readyz fails during shutdown, so that load
+	// balancers stop routing traffic before connections are closed.
+	DrainDelay        time.Duration `env:"HTTP_DRAIN_DELAY" default:"5s"`
+	HeaderReadTimeout time.Duration `env:"HTTP_HEADER_READ_TIMEOUT" default:"20s"`
+	ReadTimeout       time.Duration `env:"HTTP_READ_TIMEOUT" default:"30s"`
+	WriteTimeout      time.Duration `env:"HTTP_WRITE_TIMEOUT" default:"30s"`
+	IdleTimeout       time.Duration `env:"HTTP_IDLE_TIMEOUT" default:"120s"`
+	GinAccessLog      bool          `env:"HTTP_ACCESS_LOG" default:"false"`
+
+	// CorsOrigins is a comma separated list of allowed origins, "*" allows any.
+	CorsOrigins string `env:"HTTP_CORS_ORIGINS" default:"*" reload:"true"`
+
+	// RateLimit is the number of requests per second allowed per client with
+	// bursts of up to RateBurst requests, zero disables rate limiting.
+	RateLimit int `env:"HTTP_RATE_LIMIT" default:"0" reload:"true"`
+	RateBurst int `env:"HTTP_RATE_BURST" default:"20" reload:"true"`
+
+	// Features is a comma separated list of enabled feature flags.
+	Features string `env:"FEATURES" default:"" reload:"true"`
+
+	// AdminToken authenticates the admin endpoints, empty disables them.
+	AdminToken string `env:"ADMIN_TOKEN" default:"" secret:"true"`
+
+	// CrashReportDir keeps a report file per distinct recovered panic.
+	CrashReportDir string `env:"CRASH_REPORT_DIR" default:"crash-reports"`
+	// CrashDedupWindow reports a panic site at most once per window.
+	CrashDedupWindow time.Duration `env:"CRASH_DEDUP_WINDOW" default:"1m"`
+
+	// Health checks backing /readyz, reports are cached for HealthCacheTTL.
+	HealthCheckTimeout  time.Duration `env:"HEALTH_CHECK_TIMEOUT" default:"2s"`
+	HealthCacheTTL      time.Duration `env:"HEALTH_CACHE_TTL" default:"2s"`
+	CertExpiryThreshold time.Duration `env:"CERT_EXPIRY_THRESHOLD" default:"168h"`
 }
 
 type DatabaseConfig struct {
-	DatabaseType string `envconfig:"DATABASE_TYPE" default:"mysql"`
-	Username     string `envconfig:"USERNAME" default:"root"`
-	Password     string `envconfig:"PASSWORD" default:"root"`
-	Port         string `envconfig:"PORT" default:"3306"`
-	DbName       string `envconfig:"DATABASE_NAME" default:"testing"`
-	Url          string `envconfig:"URL" default:"127.0.0.1"`
+	DatabaseType string `env:"DB_TYPE" default:"mysql"`
+	Username     string `env:"DB_USERNAME" default:"root"`
+	Password     string `env:"DB_PASSWORD" default:"root" secret:"true"`
+	Port         string `env:"DB_PORT" default:"3306"`
+	DbName       string `env:"DB_NAME" default:"testing"`
+	Url          string `env:"DB_URL" default:"127.0.0.1"`
 	SslConfig    SSLConfig
+
+	// QueryTimeout bounds every database operation on top of the request
+	// context, zero disables the per-operation deadline.
+	QueryTimeout time.Duration `env:"DB_QUERY_TIMEOUT" default:"5s"`
+
+	// Connection pool settings, see sql.DB.SetMaxOpenConns and friends.
+	MaxOpenConns    int           `env:"DB_MAX_OPEN_CONNS" default:"10"`
+	MaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS" default:"5"`
+	ConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME" default:"30m"`
+	ConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME" default:"5m"`
+
+	// Startup readiness wait, the database is pinged with exponential backoff
+	// and jitter until it is reachable or ConnectTimeout elapses.
+	ConnectTimeout      time.Duration `env:"DB_CONNECT_TIMEOUT" default:"2m"`
+	ConnectBackoffStart time.Duration `env:"DB_CONNECT_BACKOFF_START" default:"500ms"`
+	ConnectBackoffMax   time.Duration `env:"DB_CONNECT_BACKOFF_MAX" default:"15s"`
+
+	// SchemaVersion is the minimum migration version the service requires,
+	// zero disables the readiness check.
+	SchemaVersion uint `env:"DB_SCHEMA_VERSION" default:"0"`
+}
+
+// Validate checks the database settings for nonsensical values.
+func (dbConfig DatabaseConfig) Validate() error {
+	if err := validatePort("DB_PORT", dbConfig.Port); err != nil {
+		return err
+	}
+
+	if dbConfig.MaxOpenConns <= 0 {
+		return errors.New("DB_MAX_OPEN_CONNS must be greater than 0")
+	}
+
+	if dbConfig.MaxIdleConns < 0 {
+		return errors.New("DB_MAX_IDLE_CONNS must not be negative")
+	}
+
+	if dbConfig.MaxIdleConns > dbConfig.MaxOpenConns {
+		return fmt.Errorf("DB_MAX_IDLE_CONNS (%d) must not exceed DB_MAX_OPEN_CONNS (%d)",
+			dbConfig.MaxIdleConns, dbConfig.MaxOpenConns)
+	}
+
+	if dbConfig.ConnMaxLifetime < 0 || dbConfig.ConnMaxIdleTime < 0 {
+		return errors.New("DB_CONN_MAX_LIFETIME and DB_CONN_MAX_IDLE_TIME must not be negative")
+	}
+
+	if dbConfig.ConnMaxLifetime > 0 && dbConfig.ConnMaxIdleTime > dbConfig.ConnMaxLifetime {
+		return errors.New("DB_CONN_MAX_IDLE_TIME must not exceed DB_CONN_MAX_LIFETIME")
+	}
+
+	if dbConfig.QueryTimeout < 0 {
+		return errors.New("DB_QUERY_TIMEOUT must not be negative")
+	}
+
+	if dbConfig.ConnectTimeout <= 0 || dbConfig.ConnectBackoffStart <= 0 {
+		return errors.New("DB_CONNECT_TIMEOUT and DB_CONNECT_BACKOFF_START must be greater than 0")
+	}
+
+	if dbConfig.ConnectBackoffMax < dbConfig.ConnectBackoffStart {
+		return errors.New("DB_CONNECT_BACKOFF_MAX must not be less than DB_CONNECT_BACKOFF_START")
+	}
+
+	return dbConfig.SslConfig.Validate()
 }
 
 type SSLConfig struct {
-	Sslmode    string `envconfig:"SSLMODE" default:"disable"`
-	MinTLS     string `envconfig:"MIN_TLS" default:"10"`
-	RootCA     string `envconfig:"ROOT_CA" default:"test"`
-	ServerCert string `envconfig:"SERVER_CERT" default:"test"`
-	ClientCert string `envconfig:"CLIENT_CERT" default:"test"`
-	ClientKey  string `envconfig:"CLIENT_KEY" default:"test"`
+	Sslmode    string `env:"DB_SSL_MODE" default:"disable"`
+	MinTLS     string `env:"DB_SSL_MIN_TLS" default:"1.2"`
+	RootCA     string `env:"DB_SSL_ROOT_CA" default:""`
+	ServerCert string `env:"DB_SSL_SERVER_CERT" default:""`
+	ClientCert string `env:"DB_SSL_CLIENT_CERT" default:""`
+	ClientKey  string `env:"DB_SSL_CLIENT_KEY" default:""`
+}
+
+// Validate checks the ssl mode and minimum TLS version.
+func (sslConfig SSLConfig) Validate() error {
+	switch sslConfig.Sslmode {
+	case "disable", "require", "verify-ca", "verify-full":
+	default:
+		return fmt.Errorf("DB_SSL_MODE %q must be one of disable, require, verify-ca, verify-full", sslConfig.Sslmode)
+	}
+
+	switch sslConfig.MinTLS {
+	case "1.1", "1.2", "1.3":
+	default:
+		return fmt.Errorf("DB_SSL_MIN_TLS %q must be one of 1.1, 1.2, 1.3", sslConfig.MinTLS)
+	}
+
+	return nil
+}
+
+// Validate checks the listener settings for nonsensical values.
+func (svcConfig ServiceConfig) Validate() error {
+	if _, err := log.ParseLevel(svcConfig.LogLevel); err != nil {
+		return fmt.Errorf("LOG_LEVEL %v", err)
+	}
+
+	if svcConfig.Port == 0 || svcConfig.Port > 65535 {
+		return fmt.Errorf("HTTP_PORT %d must be between 1 and 65535", svcConfig.Port)
+	}
+
+	if svcConfig.ShutdownWait <= 0 || svcConfig.HeaderReadTimeout <= 0 {
+		return errors.New("HTTP_SHUTDOWN_WAIT and HTTP_HEADER_READ_TIMEOUT must be greater than 0")
+	}
+
+	if svcConfig.DrainDelay < 0 || svcConfig.DrainDelay >= svcConfig.ShutdownWait {
+		return errors.New("HTTP_DRAIN_DELAY must not be negative and must be less than HTTP_SHUTDOWN_WAIT")
+	}
+
+	if svcConfig.ReadTimeout < 0 || svcConfig.WriteTimeout < 0 || svcConfig.IdleTimeout < 0 {
+		return errors.New("HTTP_READ_TIMEOUT, HTTP_WRITE_TIMEOUT and HTTP_IDLE_TIMEOUT must not be negative")
+	}
+
+	if svcConfig.ReadTimeout > 0 && svcConfig.HeaderReadTimeout > svcConfig.ReadTimeout {
+		return errors.New("HTTP_HEADER_READ_TIMEOUT must not exceed HTTP_READ_TIMEOUT")
+	}
+
+	if svcConfig.CrashDedupWindow < 0 {
+		return errors.New("CRASH_DEDUP_WINDOW must not be negative")
+	}
+
+	if svcConfig.RateLimit < 0 {
+		return errors.New("HTTP_RATE_LIMIT must not be negative")
+	}
+
+	if svcConfig.RateLimit > 0 && svcConfig.RateBurst < 1 {
+		return errors.New("HTTP_RATE_BURST must be at least 1 when rate limiting is enabled")
+	}
+
+	return nil
+}
+
+// CorsOriginList returns the allowed CORS origins.
+func (svcConfig ServiceConfig) CorsOriginList() []string {
+	return splitList(svcConfig.CorsOrigins)
+}
+
+// FeatureList returns the enabled feature flags.
+func (svcConfig ServiceConfig) FeatureList() []string {
+	return splitList(svcConfig.Features)
+}
+
+// splitList splits a comma separated list, dropping empty entries.
+func splitList(list string) []string {
+	var values []string
+
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
 }
 
 type MailConfig struct {
-	Host     string `envconfig:"MAIL_HOST" default:"test"`
-	Port     int    `envconfig:"MAIL_PORT" default:"test"`
-	Username string `envconfig:"MAIL_USERNAME" default:"test"`
-	Password string `envconfig:"MAIL_PASSWORD" default:"test"`
-	Sender   string `envconfig:"MAIL_SENDER" default:"test"`
+	Host     string `env:"MAIL_HOST" default:"localhost"`
+	Port     int    `env:"MAIL_PORT" default:"587"`
+	Username string `env:"MAIL_USERNAME" default:""`
+	Password string `env:"MAIL_PASSWORD" default:"" secret:"true"`
+	Sender   string `env:"MAIL_SENDER" default:"" reload:"true"`
 }
 
-func NewConfig() (*Configuration, error) {
-	var dbconfig DatabaseConfig
-	if err := envconfig.Process("", &dbconfig); err != nil {
-		return nil, fmt.Errorf("database configuration failed %v", err)
+// AuthConfig configures JWT issuing and verification.
+type AuthConfig struct {
+	Algorithm    string `env:"JWT_ALGORITHM" default:"HS256"`
+	AccessKey    string `env:"JWT_ACCESS_KEY" default:"" secret:"true"`
+	AccessKeyTTL int    `env:"JWT_ACCESS_KEY_TTL" default:"15"` // minutes
+	Issuer       string `env:"JWT_ISSUER" default:""`
+	Audience     string `env:"JWT_AUDIENCE" default:""`
+}
+
+// LogConfig selects the log sinks. Outputs are comma separated lists of stdout,
+// stderr, file and syslog. Audit entries go to the log outputs unless
+// AUDIT_OUTPUT is set, so that they can be retained independently.
+type LogConfig struct {
+	Output          string        `env:"LOG_OUTPUT" default:"stderr"`
+	File            string        `env:"LOG_FILE" default:""`
+	FileMaxSizeMB   int64         `env:"LOG_FILE_MAX_SIZE_MB" default:"100"`
+	FileRotateEvery time.Duration `env:"LOG_FILE_ROTATE_EVERY" default:"24h"`
+	FileMaxAge      time.Duration `env:"LOG_FILE_MAX_AGE" default:"168h"`
+
+	// SyslogAddress is network://host:port, empty uses the local syslog socket.
+	SyslogAddress string `env:"LOG_SYSLOG_ADDRESS" default:""`
+	SyslogTag     string `env:"LOG_SYSLOG_TAG" default:"catalogue"`
+
+	// RedactKeys masks the values of fields whose key contains one of them,
+	// RedactPatterns names the patterns masked everywhere: jwt, email, bearer.
+	RedactKeys     string `env:"LOG_REDACT_KEYS" default:"password,secret,token,authorization,email,jwt,recoverykey,twofa"`
+	RedactPatterns string `env:"LOG_REDACT_PATTERNS" default:"jwt,email,bearer"`
+
+	AuditOutput          string        `env:"AUDIT_OUTPUT" default:""`
+	AuditFile            string        `env:"AUDIT_FILE" default:""`
+	AuditFileMaxSizeMB   int64         `env:"AUDIT_FILE_MAX_SIZE_MB" default:"100"`
+	AuditFileRotateEvery time.Duration `env:"AUDIT_FILE_ROTATE_EVERY" default:"24h"`
+	AuditFileMaxAge      time.Duration `env:"AUDIT_FILE_MAX_AGE" default:"8760h"`
+}
+
+// Validate checks the outputs and the rotation limits.
+func (logConfig LogConfig) Validate() error {
+	if len(splitList(logConfig.Output)) == 0 {
+		return errors.New("LOG_OUTPUT must name at least one output")
+	}
+
+	if err := validateOutputs("LOG", logConfig.Output, logConfig.File); err != nil {
+		return err
 	}
 
-	var svcConfig ServiceConfig
-	if err := envconfig.Process("", &svcConfig); err != nil {
-		return nil, fmt.Errorf("service configuration failed %v", err)
+	if err := validateOutputs("AUDIT", logConfig.AuditOutput, logConfig.AuditFile); err != nil {
+		return err
 	}
 
-	var mailConfig MailConfig
-	if err := envconfig.Process("", &mailConfig); err != nil {
-		return nil, fmt.Errorf("mail configuration failed %v", err)
+	if hasFileOutput(logConfig.Output) && hasFileOutput(logConfig.AuditOutput) &&
+		samePath(logConfig.File, logConfig.AuditFile) {
+		return errors.New("LOG_FILE and AUDIT_FILE must not be the same file")
 	}
 
-	return &Configuration{
-		DBConfig:  dbconfig,
-		SvcConfig: svcConfig,
-		MailConf:  mailConfig,
-	}, nil
+	if _, err := logConfig.Redactor(); err != nil {
+		return fmt.Errorf("LOG_REDACT_PATTERNS %v", err)
+	}
+
+	if logConfig.FileMaxSizeMB < 0 || logConfig.FileRotateEvery < 0 || logConfig.FileMaxAge < 0 ||
+		logConfig.AuditFileMaxSizeMB < 0 || logConfig.AuditFileRotateEvery < 0 || logConfig.AuditFileMaxAge < 0 {
+		return errors.New("log file size, rotation and age limits must not be negative")
+	}
+
+	return nil
+}
+
+func hasFileOutput(outputs string) bool {
+	for _, output := range splitList(outputs) {
+		if output == log.OutputFile {
+			return true
+		}
+	}
+
+	return false
+}
+
+// samePath reports whether two paths name the same file, comparing the cleaned
+// absolute paths when the files don't exist yet.
+func samePath(a string, b string) bool {
+	aInfo, aErr := os.Stat(a)
+	bInfo, bErr := os.Stat(b)
+
+	if aErr == nil && bErr == nil {
+		return os.SameFile(aInfo, bInfo)
+	}
+
+	aAbs, aErr := filepath.Abs(a)
+	bAbs, bErr := filepath.Abs(b)
+
+	return aErr == nil && bErr == nil && aAbs == bAbs
+}
+
+func validateOutputs(prefix string, outputs string, file string) error {
+	for _, output := range splitList(outputs) {
+		switch output {
+		case log.OutputStdout, log.OutputStderr, log.OutputSyslog:
+		case log.OutputFile:
+			if file == "" {
+				return fmt.Errorf("%s_FILE must be set for the file output", prefix)
+			}
+		default:
+			return fmt.Errorf("%s_OUTPUT %q must be one of stdout, stderr, file, syslog", prefix, output)
+		}
+	}
+
+	return nil
+}
+
+// Redactor returns the redaction of the log and audit entries.
+func (logConfig LogConfig) Redactor() (*log.Redactor, error) {
+	return log.NewRedactor(splitList(logConfig.RedactKeys), splitList(logConfig.RedactPatterns))
+}
+
+// LogSink returns the sink of the log entries.
+func (logConfig LogConfig) LogSink() log.SinkConfig {
+	return log.SinkConfig{
+		Outputs: splitList(logConfig.Output),
+		File: log.RotateConfig{
+			Path:        logConfig.File,
+			MaxSize:     logConfig.FileMaxSizeMB << 20,
+			RotateEvery: logConfig.FileRotateEvery,
+			MaxAge:      logConfig.FileMaxAge,
+		},
+		SyslogAddress: logConfig.SyslogAddress,
+		SyslogTag:     logConfig.SyslogTag,
+	}
+}
+
+// AuditSink returns the sink of the audit entries, false if they share the log sink.
+func (logConfig LogConfig) AuditSink() (log.SinkConfig, bool) {
+	if logConfig.AuditOutput == "" {
+		return log.SinkConfig{}, false
+	}
+
+	return log.SinkConfig{
+		Outputs: splitList(logConfig.AuditOutput),
+		File: log.RotateConfig{
+			Path:        logConfig.AuditFile,
+			MaxSize:     logConfig.AuditFileMaxSizeMB << 20,
+			RotateEvery: logConfig.AuditFileRotateEvery,
+			MaxAge:      logConfig.AuditFileMaxAge,
+		},
+		SyslogAddress: logConfig.SyslogAddress,
+		SyslogTag:     logConfig.SyslogTag + "-audit",
+	}, true
+}
+
+// AuditConfig configures the hash-chained audit trail of movie mutations.
+// Store is file or db, empty writes audit records to the audit log stream only.
+// If HMACKey is set every SignEvery-th record is signed with it.
+type AuditConfig struct {
+	Store     string `env:"AUDIT_STORE" default:""`
+	File      string `env:"AUDIT_CHAIN_FILE" default:"audit-chain.jsonl"`
+	HMACKey   string `env:"AUDIT_HMAC_KEY" default:"" secret:"true"`
+	SignEvery uint64 `env:"AUDIT_SIGN_EVERY" default:"100"`
+}
+
+// Validate checks the store and the signing interval.
+func (auditConfig AuditConfig) Validate() error {
+	switch auditConfig.Store {
+	case "", "db":
+	case "file":
+		if auditConfig.File == "" {
+			return errors.New("AUDIT_CHAIN_FILE must be set for the file store")
+		}
+	default:
+		return fmt.Errorf("AUDIT_STORE %q must be one of file, db or empty", auditConfig.Store)
+	}
+
+	if auditConfig.HMACKey != "" && auditConfig.SignEvery == 0 {
+		return errors.New("AUDIT_SIGN_EVERY must be greater than 0 when AUDIT_HMAC_KEY is set")
+	}
+
+	return nil
+}
+
+// Validate checks every namespace and rejects settings conflicting across them.
+func (cfg *Configuration) Validate() error {
+	if err := cfg.DBConfig.Validate(); err != nil {
+		return fmt.Errorf("database configuration invalid %v", err)
+	}
+
+	if err := cfg.SvcConfig.Validate(); err != nil {
+		return fmt.Errorf("service configuration invalid %v", err)
+	}
+
+	if err := cfg.LogConf.Validate(); err != nil {
+		return fmt.Errorf("log configuration invalid %v", err)
+	}
+
+	if err := cfg.AuditConf.Validate(); err != nil {
+		return fmt.Errorf("audit configuration invalid %v", err)
+	}
+
+	if cfg.MailConf.Port <= 0 || cfg.MailConf.Port > 65535 {
+		return fmt.Errorf("mail configuration invalid MAIL_PORT %d must be between 1 and 65535", cfg.MailConf.Port)
+	}
+
+	if isLocalHost(cfg.DBConfig.Url) && cfg.DBConfig.Port == strconv.FormatUint(uint64(cfg.SvcConfig.Port), 10) {
+		return fmt.Errorf("DB_PORT and HTTP_PORT must differ for a local database, both are %s", cfg.DBConfig.Port)
+	}
+
+	// a write timeout shorter than the query timeout cuts the response before
+	// a database timeout can be reported to the client
+	if cfg.SvcConfig.WriteTimeout > 0 && cfg.SvcConfig.WriteTimeout <= cfg.DBConfig.QueryTimeout {
+		return errors.New("HTTP_WRITE_TIMEOUT must be greater than DB_QUERY_TIMEOUT")
+	}
+
+	return nil
+}
+
+func validatePort(name string, port string) error {
+	value, err := strconv.ParseUint(port, 10, 16)
+	if err != nil || value == 0 {
+		return fmt.Errorf("%s %q must be a port between 1 and 65535", name, port)
+	}
+
+	return nil
+}
+
+func isLocalHost(host string) bool {
+	return host == "" || host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+// NewConfig loads the configuration from defaults, the config file and the
+// environment, without command line flags.
+func NewConfig() (*Configuration, error) {
+	return Load(nil)
 }
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// validConfig returns a configuration passing Validate.
+func validConfig() *Configuration {
+	return &Configuration{
+		DBConfig: DatabaseConfig{
+			Url:                 "127.0.0.1",
+			Port:                "3306",
+			MaxOpenConns:        10,
+			MaxIdleConns:        5,
+			ConnMaxLifetime:     30 * time.Minute,
+			ConnMaxIdleTime:     5 * time.Minute,
+			QueryTimeout:        5 * time.Second,
+			ConnectTimeout:      2 * time.Minute,
+			ConnectBackoffStart: 500 * time.Millisecond,
+			ConnectBackoffMax:   15 * time.Second,
+			SslConfig:           SSLConfig{Sslmode: "disable", MinTLS: "1.2"},
+		},
+		SvcConfig: ServiceConfig{
+			Port:              8080,
+			ShutdownWait:      20 * time.Second,
+			DrainDelay:        5 * time.Second,
+			HeaderReadTimeout: 20 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       120 * time.Second,
+		},
+		MailConf: MailConfig{Port: 25},
+		LogConf:  LogConfig{Output: "stderr"},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *Configuration)
+		err    string
+	}{
+		{name: "valid", modify: func(cfg *Configuration) {}},
+		{
+			name:   "db port",
+			modify: func(cfg *Configuration) { cfg.DBConfig.Port = "mysql" },
+			err:    `DB_PORT "mysql" must be a port`,
+		},
+		{
+			name:   "db port zero",
+			modify: func(cfg *Configuration) { cfg.DBConfig.Port = "0" },
+			err:    `DB_PORT "0" must be a port`,
+		},
+		{
+			name:   "max open conns",
+			modify: func(cfg *Configuration) { cfg.DBConfig.MaxOpenConns = 0 },
+			err:    "DB_MAX_OPEN_CONNS must be greater than 0",
+		},
+		{
+			name:   "more idle than open conns",
+			modify: func(cfg *Configuration) { cfg.DBConfig.MaxIdleConns = 11 },
+			err:    "DB_MAX_IDLE_CONNS (11) must not exceed DB_MAX_OPEN_CONNS (10)",
+		},
+		{
+			name:   "negative lifetime",
+			modify: func(cfg *Configuration) { cfg.DBConfig.ConnMaxLifetime = -time.Second },
+			err:    "must not be negative",
+		},
+		{
+			name:   "idle time above lifetime",
+			modify: func(cfg *Configuration) { cfg.DBConfig.ConnMaxIdleTime = time.Hour },
+			err:    "DB_CONN_MAX_IDLE_TIME must not exceed DB_CONN_MAX_LIFETIME",
+		},
+		{
+			name:   "negative query timeout",
+			modify: func(cfg *Configuration) { cfg.DBConfig.QueryTimeout = -time.Second },
+			err:    "DB_QUERY_TIMEOUT must not be negative",
+		},
+		{
+			name:   "connect timeout",
+			modify: func(cfg *Configuration) { cfg.DBConfig.ConnectTimeout = 0 },
+			err:    "DB_CONNECT_TIMEOUT and DB_CONNECT_BACKOFF_START must be greater than 0",
+		},
+		{
+			name:   "backoff max below start",
+			modify: func(cfg *Configuration) { cfg.DBConfig.ConnectBackoffMax = time.Millisecond },
+			err:    "DB_CONNECT_BACKOFF_MAX must not be less than DB_CONNECT_BACKOFF_START",
+		},
+		{
+			name:   "ssl mode",
+			modify: func(cfg *Configuration) { cfg.DBConfig.SslConfig.Sslmode = "prefer" },
+			err:    `DB_SSL_MODE "prefer" must be one of`,
+		},
+		{
+			name:   "min tls",
+			modify: func(cfg *Configuration) { cfg.DBConfig.SslConfig.MinTLS = "10" },
+			err:    `DB_SSL_MIN_TLS "10" must be one of`,
+		},
+		{
+			name:   "http port",
+			modify: func(cfg *Configuration) { cfg.SvcConfig.Port = 70000 },
+			err:    "HTTP_PORT 70000 must be between 1 and 65535",
+		},
+		{
+			name:   "shutdown wait",
+			modify: func(cfg *Configuration) { cfg.SvcConfig.ShutdownWait = 0 },
+			err:    "HTTP_SHUTDOWN_WAIT and HTTP_HEADER_READ_TIMEOUT must be greater than 0",
+		},
+		{
+			name:   "drain delay",
+			modify: func(cfg *Configuration) { cfg.SvcConfig.DrainDelay = 20 * time.Second },
+			err:    "HTTP_DRAIN_DELAY must not be negative and must be less than HTTP_SHUTDOWN_WAIT",
+		},
+		{
+			name:   "negative idle timeout",
+			modify: func(cfg *Configuration) { cfg.SvcConfig.IdleTimeout = -time.Second },
+			err:    "HTTP_READ_TIMEOUT, HTTP_WRITE_TIMEOUT and HTTP_IDLE_TIMEOUT must not be negative",
+		},
+		{
+			name:   "header read timeout above read timeout",
+			modify: func(cfg *Configuration) { cfg.SvcConfig.HeaderReadTimeout = time.Minute },
+			err:    "HTTP_HEADER_READ_TIMEOUT must not exceed HTTP_READ_TIMEOUT",
+		},
+		{
+			name:   "mail port",
+			modify: func(cfg *Configuration) { cfg.MailConf.Port = 0 },
+			err:    "MAIL_PORT 0 must be between 1 and 65535",
+		},
+		{
+			name: "db and http port collide on a local database",
+			modify: func(cfg *Configuration) {
+				cfg.DBConfig.Url = "localhost"
+				cfg.DBConfig.Port = "8080"
+			},
+			err: "DB_PORT and HTTP_PORT must differ for a local database, both are 8080",
+		},
+		{
+			name: "db and http port of a remote database",
+			modify: func(cfg *Configuration) {
+				cfg.DBConfig.Url = "db.internal"
+				cfg.DBConfig.Port = "8080"
+			},
+		},
+		{
+			name:   "write timeout equal to query timeout",
+			modify: func(cfg *Configuration) { cfg.SvcConfig.WriteTimeout = 5 * time.Second },
+			err:    "HTTP_WRITE_TIMEOUT must be greater than DB_QUERY_TIMEOUT",
+		},
+		{
+			name:   "no write timeout",
+			modify: func(cfg *Configuration) { cfg.SvcConfig.WriteTimeout = 0 },
+		},
+		{
+			name:   "no log output",
+			modify: func(cfg *Configuration) { cfg.LogConf.Output = " , " },
+			err:    "LOG_OUTPUT must name at least one output",
+		},
+		{
+			name:   "unknown log output",
+			modify: func(cfg *Configuration) { cfg.LogConf.Output = "stderr,kafka" },
+			err:    `LOG_OUTPUT "kafka" must be one of`,
+		},
+		{
+			name:   "log file output without a file",
+			modify: func(cfg *Configuration) { cfg.LogConf.Output = "file" },
+			err:    "LOG_FILE must be set for the file output",
+		},
+		{
+			name:   "audit file output without a file",
+			modify: func(cfg *Configuration) { cfg.LogConf.AuditOutput = "file" },
+			err:    "AUDIT_FILE must be set for the file output",
+		},
+		{
+			name: "log and audit share a file",
+			modify: func(cfg *Configuration) {
+				cfg.LogConf.Output, cfg.LogConf.File = "file", "/var/log/catalogue.log"
+				cfg.LogConf.AuditOutput, cfg.LogConf.AuditFile = "file", "/var/log/../log/catalogue.log"
+			},
+			err: "LOG_FILE and AUDIT_FILE must not be the same file",
+		},
+		{
+			name: "log and audit files",
+			modify: func(cfg *Configuration) {
+				cfg.LogConf.Output, cfg.LogConf.File = "file", "/var/log/catalogue.log"
+				cfg.LogConf.AuditOutput, cfg.LogConf.AuditFile = "file", "/var/log/audit.log"
+			},
+		},
+		{
+			name:   "negative rotation",
+			modify: func(cfg *Configuration) { cfg.LogConf.FileRotateEvery = -time.Hour },
+			err:    "log file size, rotation and age limits must not be negative",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := validConfig()
+			test.modify(cfg)
+
+			err := cfg.Validate()
+
+			switch {
+			case test.err == "" && err != nil:
+				t.Errorf("Validate failed: %v", err)
+			case test.err != "" && err == nil:
+				t.Errorf("Validate passed, want %q", test.err)
+			case test.err != "" && !strings.Contains(err.Error(), test.err):
+				t.Errorf("got error %q, want %q", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,371 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"catalogue-app/internal/pkg/secrets"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Sources a setting value can come from, later ones override earlier ones.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+
+	// SourceSecret prefixes the name of the secrets provider a value came from.
+	SourceSecret = "secret:"
+)
+
+// configFileEnv names the environment variable pointing to the config file,
+// the --config flag takes precedence over it.
+const configFileEnv = "CONFIG_FILE"
+
+// The encrypted secrets file and its base64 encoded AES-256 key, the key may
+// itself be provided through SECRETS_KEY_FILE.
+const (
+	secretsFileEnv = "SECRETS_FILE"
+	secretsKeyEnv  = "SECRETS_KEY"
+)
+
+const redacted = "[REDACTED]"
+
+// Setting is the effective value of a single configuration key.
+type Setting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// field is a settable leaf of the Configuration struct. Key is the environment
+// variable name from the env tag, file keys and flags are derived from it.
+type field struct {
+	key          string
+	defaultValue string
+	secret       bool
+	reload       bool
+	value        reflect.Value
+}
+
+// flagName returns the command line flag for an environment variable name,
+// DB_MAX_OPEN_CONNS becomes --db-max-open-conns.
+func flagName(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", "-"))
+}
+
+// Load builds the configuration in layers: defaults, then the YAML file given by
+// --config or CONFIG_FILE, then environment variables, then command line flags.
+// Fields tagged secret can't be set by flags, instead they are looked up in the
+// *_FILE environment variables, the given providers and the encrypted SECRETS_FILE,
+// in that order, overriding the other layers. The result is validated before it
+// is returned.
+func Load(args []string, providers ...secrets.Provider) (*Configuration, error) {
+	cfg := &Configuration{sources: make(map[string]string), args: args, providers: providers}
+	fields := collectFields(reflect.ValueOf(cfg).Elem())
+
+	flagSet := flag.NewFlagSet("config", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+
+	configFile := flagSet.String("config", os.Getenv(configFileEnv), "path to a YAML configuration file")
+	flagValues := make(map[string]*string, len(fields))
+
+	for _, f := range fields {
+		// secrets passed as flags would be visible in the process list
+		if !f.secret {
+			flagValues[f.key] = flagSet.String(flagName(f.key), "", "overrides "+f.key)
+		}
+	}
+
+	if err := flagSet.Parse(args); err != nil {
+		return nil, fmt.Errorf("configuration flags failed %v", err)
+	}
+
+	var fileValues map[string]string
+	if *configFile != "" {
+		var err error
+		if fileValues, err = readFile(*configFile); err != nil {
+			return nil, fmt.Errorf("configuration file failed %v", err)
+		}
+	}
+
+	secretChain, err := newSecretChain(providers)
+	if err != nil {
+		return nil, fmt.Errorf("secrets configuration failed %v", err)
+	}
+
+	setFlags := make(map[string]bool)
+	flagSet.Visit(func(f *flag.Flag) {
+		setFlags[f.Name] = true
+	})
+
+	for _, f := range fields {
+		value, source := f.defaultValue, SourceDefault
+
+		if fileValue, ok := fileValues[f.key]; ok {
+			value, source = fileValue, SourceFile
+			delete(fileValues, f.key)
+		}
+
+		if envValue, ok := os.LookupEnv(f.key); ok {
+			value, source = envValue, SourceEnv
+		}
+
+		if setFlags[flagName(f.key)] {
+			value, source = *flagValues[f.key], SourceFlag
+		}
+
+		if f.secret {
+			secretValue, provider, found, err := secretChain.Lookup(context.Background(), f.key)
+			if err != nil {
+				return nil, fmt.Errorf("configuration %s secret failed %v", f.key, err)
+			}
+
+			if found {
+				value, source = secretValue, SourceSecret+provider
+			}
+		}
+
+		if err := setValue(f.value, value); err != nil {
+			return nil, fmt.Errorf("configuration %s from %s failed %v", f.key, source, err)
+		}
+
+		cfg.sources[f.key] = source
+	}
+
+	for key := range fileValues {
+		return nil, fmt.Errorf("configuration file failed unknown key %s", key)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.checkDefaultCredentials(fields); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// newSecretChain returns the *_FILE provider, the given providers and the
+// encrypted secrets file provider if SECRETS_FILE is set.
+func newSecretChain(providers []secrets.Provider) (secrets.Chain, error) {
+	chain := secrets.Chain{secrets.FileEnv{}}
+	chain = append(chain, providers...)
+
+	path := os.Getenv(secretsFileEnv)
+	if path == "" {
+		return chain, nil
+	}
+
+	key, err := SecretsKey()
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedFile, err := secrets.NewEncryptedFile(path, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(chain, encryptedFile), nil
+}
+
+// SecretsKey returns the key of the encrypted secrets file from SECRETS_KEY_FILE,
+// or from SECRETS_KEY if that is not set.
+func SecretsKey() (string, error) {
+	key, found, err := secrets.FileEnv{}.Lookup(context.Background(), secretsKeyEnv)
+	if err != nil {
+		return "", err
+	}
+
+	if !found {
+		key = os.Getenv(secretsKeyEnv)
+	}
+
+	return key, nil
+}
+
+// checkDefaultCredentials refuses secrets left at their built-in default and a
+// missing HMAC signing key, unless DEV_MODE is explicitly on.
+func (cfg *Configuration) checkDefaultCredentials(fields []field) error {
+	if cfg.SvcConfig.DevMode {
+		return nil
+	}
+
+	for _, f := range fields {
+		if f.secret && f.defaultValue != "" && f.value.String() == f.defaultValue {
+			return fmt.Errorf("refusing to start with the default %s, set it or enable DEV_MODE", f.key)
+		}
+	}
+
+	if strings.HasPrefix(cfg.AuthConf.Algorithm, "HS") && cfg.AuthConf.AccessKey == "" {
+		return errors.New("refusing to start without JWT_ACCESS_KEY, set it or enable DEV_MODE")
+	}
+
+	return nil
+}
+
+// Settings returns the effective configuration sorted by key, along with the
+// source of every value. Passwords and keys are redacted.
+func (cfg *Configuration) Settings() []Setting {
+	fields := collectFields(reflect.ValueOf(cfg).Elem())
+	settings := make([]Setting, 0, len(fields))
+
+	for _, f := range fields {
+		value := formatValue(f.value)
+		if f.secret && value != "" {
+			value = redacted
+		}
+
+		settings = append(settings, Setting{Key: f.key, Value: value, Source: cfg.sources[f.key]})
+	}
+
+	sort.Slice(settings, func(i, j int) bool {
+		return settings[i].Key < settings[j].Key
+	})
+
+	return settings
+}
+
+// Print writes the effective configuration in KEY=value form with the source of every value.
+func (cfg *Configuration) Print(w io.Writer) error {
+	for _, setting := range cfg.Settings() {
+		if _, err := fmt.Fprintf(w, "%s=%s\t# %s\n", setting.Key, setting.Value, setting.Source); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func collectFields(value reflect.Value) []field {
+	var fields []field
+
+	for i := 0; i < value.NumField(); i++ {
+		structField := value.Type().Field(i)
+		fieldValue := value.Field(i)
+
+		if !structField.IsExported() {
+			continue
+		}
+
+		key := structField.Tag.Get("env")
+		if key == "" {
+			if fieldValue.Kind() == reflect.Struct {
+				fields = append(fields, collectFields(fieldValue)...)
+			}
+
+			continue
+		}
+
+		fields = append(fields, field{
+			key:          key,
+			defaultValue: structField.Tag.Get("default"),
+			secret:       structField.Tag.Get("secret") == "true",
+			reload:       structField.Tag.Get("reload") == "true",
+			value:        fieldValue,
+		})
+	}
+
+	return fields
+}
+
+// readFile reads a YAML file into environment variable style keys, nested
+// sections are joined with underscores so that db: {ssl: {mode: x}} sets DB_SSL_MODE.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var document map[string]interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	flatten("", document, values)
+
+	return values, nil
+}
+
+func flatten(prefix string, document map[string]interface{}, values map[string]string) {
+	for key, value := range document {
+		key = strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+
+		if section, ok := value.(map[string]interface{}); ok {
+			flatten(key, section, values)
+
+			continue
+		}
+
+		values[key] = fmt.Sprint(value)
+	}
+}
+
+func setValue(value reflect.Value, raw string) error {
+	if value.Type() == reflect.TypeOf(time.Duration(0)) {
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+
+		value.SetInt(int64(duration))
+
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+
+		value.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(raw, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		value.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(raw, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		value.SetUint(parsed)
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+
+	return nil
+}
+
+func formatValue(value reflect.Value) string {
+	if duration, ok := value.Interface().(time.Duration); ok {
+		return duration.String()
+	}
+
+	return fmt.Sprint(value.Interface())
+}
@@ -0,0 +1,400 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"catalogue-app/internal/pkg/secrets"
+)
+
+// clearEnv unsets every configuration variable for the duration of the test,
+// so that the environment running the tests can't leak into them.
+func clearEnv(t *testing.T) {
+	t.Helper()
+
+	keys := []string{configFileEnv, secretsFileEnv, secretsKeyEnv, secretsKeyEnv + "_FILE"}
+	for _, f := range collectFields(reflect.ValueOf(&Configuration{}).Elem()) {
+		keys = append(keys, f.key, f.key+"_FILE")
+	}
+
+	for _, key := range keys {
+		if value, ok := os.LookupEnv(key); ok {
+			os.Unsetenv(key)
+			t.Cleanup(func() {
+				os.Setenv(key, value)
+			})
+		}
+	}
+}
+
+// devEnv is clearEnv with DEV_MODE on, so that the default credentials pass.
+func devEnv(t *testing.T) {
+	t.Helper()
+
+	clearEnv(t)
+	t.Setenv("DEV_MODE", "true")
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func setting(t *testing.T, cfg *Configuration, key string) Setting {
+	t.Helper()
+
+	for _, s := range cfg.Settings() {
+		if s.Key == key {
+			return s
+		}
+	}
+
+	t.Fatalf("no setting %s", key)
+
+	return Setting{}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   string
+		env    string
+		flag   string
+		value  string
+		source string
+	}{
+		{name: "default", value: "8080", source: SourceDefault},
+		{name: "file", file: "8081", value: "8081", source: SourceFile},
+		{name: "env over file", file: "8081", env: "8082", value: "8082", source: SourceEnv},
+		{name: "flag over env", file: "8081", env: "8082", flag: "8083", value: "8083", source: SourceFlag},
+		{name: "flag over default", flag: "8083", value: "8083", source: SourceFlag},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			devEnv(t)
+
+			var args []string
+
+			if test.file != "" {
+				args = append(args, "--config", writeConfigFile(t, "http:\n  port: "+test.file+"\n"))
+			}
+
+			if test.env != "" {
+				t.Setenv("HTTP_PORT", test.env)
+			}
+
+			if test.flag != "" {
+				args = append(args, "--http-port="+test.flag)
+			}
+
+			cfg, err := Load(args)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+
+			got := setting(t, cfg, "HTTP_PORT")
+			if got.Value != test.value || got.Source != test.source {
+				t.Errorf("got %s from %s, want %s from %s", got.Value, got.Source, test.value, test.source)
+			}
+
+			if got := setting(t, cfg, "DB_PORT"); got.Value != "3306" || got.Source != SourceDefault {
+				t.Errorf("unrelated DB_PORT is %s from %s", got.Value, got.Source)
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	devEnv(t)
+
+	path := writeConfigFile(t, `
+db:
+  max-open-conns: 20
+  ssl:
+    mode: require
+http:
+  read_timeout: 45s
+log:
+  level: debug
+`)
+	t.Setenv(configFileEnv, path)
+
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.DBConfig.MaxOpenConns != 20 || cfg.DBConfig.SslConfig.Sslmode != "require" ||
+		cfg.SvcConfig.ReadTimeout.String() != "45s" || cfg.SvcConfig.LogLevel != "debug" {
+		t.Errorf("file values not applied: %+v %+v", cfg.DBConfig, cfg.SvcConfig)
+	}
+
+	// the flag takes precedence over CONFIG_FILE
+	other := writeConfigFile(t, "db:\n  max-open-conns: 30\n")
+
+	if cfg, err = Load([]string{"--config", other}); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.DBConfig.MaxOpenConns != 30 || cfg.DBConfig.SslConfig.Sslmode != "disable" {
+		t.Errorf("--config didn't override CONFIG_FILE: %+v", cfg.DBConfig)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		args []string
+		err  string
+	}{
+		{
+			name: "unknown file key",
+			file: "db:\n  colour: blue\n",
+			err:  "configuration file failed unknown key DB_COLOUR",
+		},
+		{
+			name: "invalid yaml",
+			file: "db: [",
+			err:  "configuration file failed",
+		},
+		{
+			name: "missing file",
+			args: []string{"--config", filepath.Join(os.TempDir(), "missing-config.yaml")},
+			err:  "configuration file failed",
+		},
+		{
+			name: "invalid file value",
+			file: "http:\n  port: http\n",
+			err:  "configuration HTTP_PORT from file failed",
+		},
+		{
+			name: "invalid env value",
+			env:  map[string]string{"DB_CONNECT_TIMEOUT": "soon"},
+			err:  "configuration DB_CONNECT_TIMEOUT from env failed",
+		},
+		{
+			name: "invalid flag value",
+			args: []string{"--http-access-log=maybe"},
+			err:  "configuration HTTP_ACCESS_LOG from flag failed",
+		},
+		{
+			name: "unknown flag",
+			args: []string{"--colour=blue"},
+			err:  "configuration flags failed",
+		},
+		{
+			name: "validation",
+			env:  map[string]string{"DB_MAX_OPEN_CONNS": "0"},
+			err:  "database configuration invalid DB_MAX_OPEN_CONNS must be greater than 0",
+		},
+		{
+			name: "cross-field validation",
+			args: []string{"--http-port=3306"},
+			err:  "DB_PORT and HTTP_PORT must differ",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			devEnv(t)
+
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			args := test.args
+			if test.file != "" {
+				args = append(args, "--config", writeConfigFile(t, test.file))
+			}
+
+			_, err := Load(args)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("got error %v, want %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestSettingsRedactSecrets(t *testing.T) {
+	devEnv(t)
+	t.Setenv("DB_PASSWORD", "hunter2")
+	t.Setenv("ADMIN_TOKEN", "admin-secret")
+
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if got := setting(t, cfg, "DB_PASSWORD"); got.Value != redacted || got.Source != SourceEnv {
+		t.Errorf("DB_PASSWORD is %+v, want it redacted", got)
+	}
+
+	if got := setting(t, cfg, "ADMIN_TOKEN"); got.Value != redacted {
+		t.Errorf("ADMIN_TOKEN is %+v, want it redacted", got)
+	}
+
+	// empty secrets are shown, so that a missing credential is visible
+	if got := setting(t, cfg, "MAIL_PASSWORD"); got.Value != "" {
+		t.Errorf("MAIL_PASSWORD is %+v, want it empty", got)
+	}
+
+	var printed strings.Builder
+	if err := cfg.Print(&printed); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, secret := range []string{"hunter2", "admin-secret"} {
+		if strings.Contains(printed.String(), secret) {
+			t.Errorf("Print shows %q:\n%s", secret, printed.String())
+		}
+	}
+
+	if !strings.Contains(printed.String(), "HTTP_PORT=8080\t# default\n") {
+		t.Errorf("Print lacks HTTP_PORT:\n%s", printed.String())
+	}
+}
+
+func TestLoadDefaultCredentials(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		err  string
+	}{
+		{
+			name: "default db password",
+			env:  map[string]string{"JWT_ACCESS_KEY": "access-key"},
+			err:  "refusing to start with the default DB_PASSWORD",
+		},
+		{
+			name: "missing jwt key",
+			env:  map[string]string{"DB_PASSWORD": "hunter2"},
+			err:  "refusing to start without JWT_ACCESS_KEY",
+		},
+		{
+			name: "asymmetric jwt without key",
+			env:  map[string]string{"DB_PASSWORD": "hunter2", "JWT_ALGORITHM": "RS256"},
+		},
+		{
+			name: "credentials set",
+			env:  map[string]string{"DB_PASSWORD": "hunter2", "JWT_ACCESS_KEY": "access-key"},
+		},
+		{
+			name: "dev mode",
+			env:  map[string]string{"DEV_MODE": "true"},
+		},
+		{
+			name: "dev mode off",
+			env:  map[string]string{"DEV_MODE": "false", "JWT_ACCESS_KEY": "access-key"},
+			err:  "refusing to start with the default DB_PASSWORD",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clearEnv(t)
+
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			_, err := Load(nil)
+			if test.err == "" && err != nil {
+				t.Errorf("Load failed: %v", err)
+			}
+
+			if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Errorf("got error %v, want %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestLoadSecretFlagRejected(t *testing.T) {
+	devEnv(t)
+
+	for _, arg := range []string{"--db-password=hunter2", "--admin-token=admin-secret", "--jwt-access-key=key"} {
+		if _, err := Load([]string{arg}); err == nil || !strings.Contains(err.Error(), "configuration flags failed") {
+			t.Errorf("%s: got error %v, want the flag rejected", arg, err)
+		}
+	}
+}
+
+func TestLoadSecretProviders(t *testing.T) {
+	devEnv(t)
+
+	key := make([]byte, 32)
+	ciphertext, err := secrets.Encrypt([]byte("DB_PASSWORD: from-encrypted\nMAIL_PASSWORD: mail-encrypted\nJWT_ACCESS_KEY: jwt-encrypted\n"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encryptedPath := filepath.Join(t.TempDir(), "secrets.enc")
+	if err := os.WriteFile(encryptedPath, ciphertext, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	keyPath := filepath.Join(t.TempDir(), "secrets.key")
+	if err := os.WriteFile(keyPath, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	passwordPath := filepath.Join(t.TempDir(), "db_password")
+	if err := os.WriteFile(passwordPath, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(secretsFileEnv, encryptedPath)
+	t.Setenv(secretsKeyEnv+"_FILE", keyPath)
+	t.Setenv("DB_PASSWORD", "from-env")
+	t.Setenv("DB_PASSWORD_FILE", passwordPath)
+	t.Setenv("MAIL_PASSWORD", "mail-env")
+
+	cfg, err := Load(nil, secrets.Static{"MAIL_PASSWORD": "mail-static"})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	tests := []struct {
+		key    string
+		value  string
+		source string
+	}{
+		{key: "DB_PASSWORD", value: "from-file", source: SourceSecret + "file-env"},
+		{key: "MAIL_PASSWORD", value: "mail-static", source: SourceSecret + "static"},
+		{key: "JWT_ACCESS_KEY", value: "jwt-encrypted", source: SourceSecret + "encrypted-file"},
+	}
+
+	values := map[string]string{
+		"DB_PASSWORD":    cfg.DBConfig.Password,
+		"MAIL_PASSWORD":  cfg.MailConf.Password,
+		"JWT_ACCESS_KEY": cfg.AuthConf.AccessKey,
+	}
+
+	for _, test := range tests {
+		if got := setting(t, cfg, test.key); values[test.key] != test.value || got.Source != test.source {
+			t.Errorf("%s is %q from %s, want %q from %s", test.key, values[test.key], got.Source, test.value, test.source)
+		}
+	}
+
+	// a wrong key fails loudly instead of silently falling back to the defaults
+	t.Setenv(secretsKeyEnv+"_FILE", "")
+	t.Setenv(secretsKeyEnv, base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32)))
+
+	if _, err := Load(nil); err == nil || !strings.Contains(err.Error(), "secrets configuration failed") {
+		t.Errorf("got error %v, want the decryption to fail", err)
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"reflect"
+)
+
+// Change is a setting differing between two configurations.
+type Change struct {
+	Key string `json:"key"`
+	Old string `json:"old"`
+	New string `json:"new"`
+
+	// Reloadable settings are applied at runtime, others need a restart.
+	Reloadable bool `json:"reloadable"`
+}
+
+// Reload loads the configuration again from the same flags and secret
+// providers, picking up changes to the config file, environment and secrets.
+func (cfg *Configuration) Reload() (*Configuration, error) {
+	return Load(cfg.args, cfg.providers...)
+}
+
+// Diff returns the settings of other differing from cfg, secrets are redacted.
+func (cfg *Configuration) Diff(other *Configuration) []Change {
+	oldFields := collectFields(reflect.ValueOf(cfg).Elem())
+	newFields := collectFields(reflect.ValueOf(other).Elem())
+
+	var changes []Change
+
+	for i, oldField := range oldFields {
+		oldValue, newValue := formatValue(oldField.value), formatValue(newFields[i].value)
+		if oldValue == newValue {
+			continue
+		}
+
+		if oldField.secret {
+			oldValue, newValue = redacted, redacted
+		}
+
+		changes = append(changes, Change{
+			Key:        oldField.key,
+			Old:        oldValue,
+			New:        newValue,
+			Reloadable: oldField.reload,
+		})
+	}
+
+	return changes
+}
+
+// WithReloadable returns a copy of cfg with the reloadable settings taken from other.
+func (cfg *Configuration) WithReloadable(other *Configuration) *Configuration {
+	applied := *cfg
+	applied.sources = make(map[string]string, len(cfg.sources))
+
+	for key, source := range cfg.sources {
+		applied.sources[key] = source
+	}
+
+	appliedFields := collectFields(reflect.ValueOf(&applied).Elem())
+	newFields := collectFields(reflect.ValueOf(other).Elem())
+
+	for i, appliedField := range appliedFields {
+		if appliedField.reload {
+			appliedField.value.Set(newFields[i].value)
+			applied.sources[appliedField.key] = other.sources[appliedField.key]
+		}
+	}
+
+	return &applied
+}
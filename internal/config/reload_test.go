@@ -0,0 +1,178 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"catalogue-app/internal/pkg/secrets"
+)
+
+func TestDiff(t *testing.T) {
+	devEnv(t)
+
+	current, err := Load([]string{"--log-level=info"})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		changes []Change
+	}{
+		{
+			name: "unchanged",
+		},
+		{
+			name: "reloadable",
+			env:  map[string]string{"HTTP_RATE_LIMIT": "50", "MAIL_SENDER": "noreply@example.com"},
+			changes: []Change{
+				{Key: "HTTP_RATE_LIMIT", Old: "0", New: "50", Reloadable: true},
+				{Key: "MAIL_SENDER", Old: "", New: "noreply@example.com", Reloadable: true},
+			},
+		},
+		{
+			name: "restart required",
+			env:  map[string]string{"DB_MAX_OPEN_CONNS": "20"},
+			changes: []Change{
+				{Key: "DB_MAX_OPEN_CONNS", Old: "10", New: "20"},
+			},
+		},
+		{
+			name: "secrets redacted",
+			env:  map[string]string{"DB_PASSWORD": "hunter2", "ADMIN_TOKEN": "admin-secret"},
+			changes: []Change{
+				{Key: "ADMIN_TOKEN", Old: redacted, New: redacted},
+				{Key: "DB_PASSWORD", Old: redacted, New: redacted},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			reloaded, err := current.Reload()
+			if err != nil {
+				t.Fatalf("Reload failed: %v", err)
+			}
+
+			changes := current.Diff(reloaded)
+			if len(changes) != len(test.changes) {
+				t.Fatalf("got changes %+v, want %+v", changes, test.changes)
+			}
+
+			for _, want := range test.changes {
+				found := false
+				for _, change := range changes {
+					if change == want {
+						found = true
+					}
+				}
+
+				if !found {
+					t.Errorf("got changes %+v, missing %+v", changes, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	devEnv(t)
+	t.Setenv("HTTP_RATE_LIMIT", "10")
+
+	provider := secrets.Static{"MAIL_PASSWORD": "mail-secret"}
+
+	current, err := Load([]string{"--log-level=warn"}, provider)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	t.Setenv("HTTP_RATE_LIMIT", "50")
+	t.Setenv("DB_MAX_OPEN_CONNS", "20")
+	t.Setenv("LOG_LEVEL", "debug")
+
+	reloaded, err := current.Reload()
+	if err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	// flags and providers are kept, the environment is read again
+	if reloaded.SvcConfig.LogLevel != "warn" || reloaded.MailConf.Password != "mail-secret" {
+		t.Errorf("Reload lost the flags or providers: %+v %+v", reloaded.SvcConfig, reloaded.MailConf)
+	}
+
+	if reloaded.SvcConfig.RateLimit != 50 || reloaded.DBConfig.MaxOpenConns != 20 {
+		t.Errorf("Reload didn't read the environment: %+v %+v", reloaded.SvcConfig, reloaded.DBConfig)
+	}
+
+	applied := current.WithReloadable(reloaded)
+
+	if applied.SvcConfig.RateLimit != 50 || setting(t, applied, "HTTP_RATE_LIMIT").Source != SourceEnv {
+		t.Errorf("reloadable HTTP_RATE_LIMIT not applied: %+v", setting(t, applied, "HTTP_RATE_LIMIT"))
+	}
+
+	if applied.DBConfig.MaxOpenConns != 10 {
+		t.Errorf("DB_MAX_OPEN_CONNS applied without a restart: %d", applied.DBConfig.MaxOpenConns)
+	}
+
+	if current.SvcConfig.RateLimit != 10 {
+		t.Errorf("WithReloadable modified the current configuration: %d", current.SvcConfig.RateLimit)
+	}
+
+	// an invalid change is rejected and leaves nothing to apply
+	t.Setenv("DB_MAX_OPEN_CONNS", "0")
+
+	if _, err := current.Reload(); err == nil || !strings.Contains(err.Error(), "DB_MAX_OPEN_CONNS") {
+		t.Errorf("got error %v, want the invalid DB_MAX_OPEN_CONNS rejected", err)
+	}
+}
+
+func TestReloadTags(t *testing.T) {
+	fields := make(map[string]field)
+
+	for _, f := range collectFields(reflect.ValueOf(&Configuration{}).Elem()) {
+		fields[f.key] = f
+
+		if f.reload && f.secret {
+			t.Errorf("%s is both reloadable and secret, its changes could not be reported", f.key)
+		}
+	}
+
+	tests := []struct {
+		key    string
+		reload bool
+		secret bool
+	}{
+		{key: "LOG_LEVEL", reload: true},
+		{key: "HTTP_CORS_ORIGINS", reload: true},
+		{key: "HTTP_RATE_LIMIT", reload: true},
+		{key: "HTTP_RATE_BURST", reload: true},
+		{key: "FEATURES", reload: true},
+		{key: "MAIL_SENDER", reload: true},
+		{key: "HTTP_PORT"},
+		{key: "DB_URL"},
+		{key: "DB_MAX_OPEN_CONNS"},
+		{key: "DB_PASSWORD", secret: true},
+		{key: "MAIL_PASSWORD", secret: true},
+		{key: "JWT_ACCESS_KEY", secret: true},
+		{key: "ADMIN_TOKEN", secret: true},
+	}
+
+	for _, test := range tests {
+		f, ok := fields[test.key]
+		if !ok {
+			t.Errorf("no setting %s", test.key)
+
+			continue
+		}
+
+		if f.reload != test.reload || f.secret != test.secret {
+			t.Errorf("%s has reload %v secret %v, want reload %v secret %v", test.key, f.reload, f.secret, test.reload, test.secret)
+		}
+	}
+}
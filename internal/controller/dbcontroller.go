@@ -2,12 +2,21 @@ package controller
 
 import (
 	db "catalogue-app/internal/database"
+	"catalogue-app/internal/pkg/audit"
+	gerror "catalogue-app/internal/pkg/error"
+	"catalogue-app/internal/pkg/log"
 	"catalogue-app/internal/pkg/model"
+	"catalogue-app/internal/pkg/validator"
 	"context"
+	"strconv"
 )
 
+// movieResource names movies in audit records.
+const movieResource = "movie"
+
 type MovieController struct {
 	dbClient db.DBCLientIntfc
+	auditor  audit.Recorder
 }
 
 type ControllerIntfc interface {
@@ -15,11 +24,12 @@ type ControllerIntfc interface {
 	GetMovieByID(ctx context.Context, movieID string) (model.MovieInfo, error)
 	CreateMovie(ctx context.Context, movieInfo model.MovieInfo) (model.MovieInfo, error)
 	UpdateMovie(ctx context.Context, movieInfo model.MovieInfo, movieID string) (model.MovieInfo, error)
+	PatchMovie(ctx context.Context, patch model.MoviePatch, movieID string) (model.MovieInfo, error)
 	DeleteMovie(ctx context.Context, movieID string) error
 }
 
-func NewMovieController(dbClient db.DBCLientIntfc) *MovieController {
-	return &MovieController{dbClient: dbClient}
+func NewMovieController(dbClient db.DBCLientIntfc, auditor audit.Recorder) *MovieController {
+	return &MovieController{dbClient: dbClient, auditor: auditor}
 }
 
 func (movieController MovieController) GetMovies(ctx context.Context) ([]model.MovieInfo, error) {
@@ -41,7 +51,20 @@ func (movieController MovieController) GetMovieByID(ctx context.Context, movieID
 }
 
 func (movieController MovieController) CreateMovie(ctx context.Context, movieInfo model.MovieInfo) (model.MovieInfo, error) {
-	movie, err := movieController.dbClient.CreateMovie(ctx, movieInfo)
+	if err := validateMovie(ctx, &movieInfo); err != nil {
+		return model.MovieInfo{}, err
+	}
+
+	var movie model.MovieInfo
+
+	err := movieController.dbClient.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		if movie, err = movieController.dbClient.CreateMovie(ctx, movieInfo); err != nil {
+			return err
+		}
+
+		return movieController.audit(ctx, "create", strconv.FormatInt(movie.ID, 10), nil, movie)
+	})
 	if err != nil {
 		return model.MovieInfo{}, err
 	}
@@ -50,7 +73,25 @@ func (movieController MovieController) CreateMovie(ctx context.Context, movieInf
 }
 
 func (movieController MovieController) UpdateMovie(ctx context.Context, movieInfo model.MovieInfo, movieID string) (model.MovieInfo, error) {
-	movie, err := movieController.dbClient.UpdateMovie(ctx, movieInfo, movieID)
+	if err := validateMovie(ctx, &movieInfo); err != nil {
+		return model.MovieInfo{}, err
+	}
+
+	var movie model.MovieInfo
+
+	err := movieController.dbClient.Transaction(ctx, func(ctx context.Context) error {
+		// the before image is read with the movie locked until the change commits
+		before, err := movieController.dbClient.GetMovieByID(ctx, movieID)
+		if err != nil {
+			return err
+		}
+
+		if movie, err = movieController.dbClient.UpdateMovie(ctx, movieInfo, movieID); err != nil {
+			return err
+		}
+
+		return movieController.audit(ctx, "update", movieID, before, movie)
+	})
 	if err != nil {
 		return model.MovieInfo{}, err
 	}
@@ -58,11 +99,75 @@ func (movieController MovieController) UpdateMovie(ctx context.Context, movieInf
 	return movie, nil
 }
 
-func (movieController MovieController) DeleteMovie(ctx context.Context, movieID string) error {
-	err := movieController.dbClient.DeleteMovie(ctx, movieID)
+// PatchMovie applies a partial update, the patched movie is validated as a whole.
+// The movie is read, patched and saved in one transaction with its row locked,
+// so that concurrent patches of other fields are not lost.
+func (movieController MovieController) PatchMovie(ctx context.Context, patch model.MoviePatch, movieID string) (model.MovieInfo, error) {
+	var movie model.MovieInfo
+
+	err := movieController.dbClient.Transaction(ctx, func(ctx context.Context) error {
+		before, err := movieController.dbClient.GetMovieByID(ctx, movieID)
+		if err != nil {
+			return err
+		}
+
+		movieInfo := patch.Apply(before)
+		if err := validateMovie(ctx, &movieInfo); err != nil {
+			return err
+		}
+
+		if movie, err = movieController.dbClient.UpdateMovie(ctx, movieInfo, movieID); err != nil {
+			return err
+		}
+
+		return movieController.audit(ctx, "update", movieID, before, movie)
+	})
 	if err != nil {
+		return model.MovieInfo{}, err
+	}
+
+	return movie, nil
+}
+
+func (movieController MovieController) DeleteMovie(ctx context.Context, movieID string) error {
+	return movieController.dbClient.Transaction(ctx, func(ctx context.Context) error {
+		// the before image is read with the movie locked until the change commits
+		before, err := movieController.dbClient.GetMovieByID(ctx, movieID)
+		if err != nil {
+			return err
+		}
+
+		if err := movieController.dbClient.DeleteMovie(ctx, movieID); err != nil {
+			return err
+		}
+
+		return movieController.audit(ctx, "delete", movieID, before, nil)
+	})
+}
+
+// audit records a mutation inside its transaction, a failure rolls the
+// mutation back so that no change goes unrecorded.
+func (movieController MovieController) audit(ctx context.Context, action string, movieID string, before interface{}, after interface{}) error {
+	entry := audit.Entry{Action: action, Resource: movieResource, ResourceID: movieID, Before: before, After: after}
+
+	if err := movieController.auditor.Record(ctx, entry); err != nil {
+		log.Errorf(ctx, "unable to audit %s of movie %s: %v", action, movieID, err)
+
 		return err
 	}
 
 	return nil
 }
+
+// validateMovie runs MovieValidator and returns all failed fields in a
+// FailedDataValidation error, in the language of the context.
+func validateMovie(ctx context.Context, movie *model.MovieInfo) error {
+	v := validator.New()
+	validator.MovieValidator(v, movie)
+
+	if v.Valid() {
+		return nil
+	}
+
+	return gerror.NewWithFields(gerror.FailedDataValidation, "movie validation failed", v.Messages(ctx))
+}
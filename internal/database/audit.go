@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"sync"
+
+	"catalogue-app/internal/pkg/audit"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AuditStore keeps the audit chain in the audit_records table. Inside
+// MovieClient.Transaction records are appended in the transaction of the
+// audited change, and the last record is locked so that concurrent appends,
+// also from other instances, are serialized.
+type AuditStore struct {
+	dbClient *gorm.DB
+
+	mutex    sync.Mutex
+	migrated bool
+}
+
+// NewAuditStore returns an AuditStore, the table is created on first use so
+// that the database needn't be reachable at startup.
+func NewAuditStore(dbClient *gorm.DB) *AuditStore {
+	return &AuditStore{dbClient: dbClient}
+}
+
+// Append inserts the record, a duplicate sequence number fails.
+func (store *AuditStore) Append(ctx context.Context, record audit.Record) error {
+	if err := store.migrate(ctx); err != nil {
+		return err
+	}
+
+	if err := store.session(ctx).Table("audit_records").Create(&record).Error; err != nil {
+		return dbError(ctx, err)
+	}
+
+	return nil
+}
+
+// Last returns the record with the highest sequence number.
+func (store *AuditStore) Last(ctx context.Context) (audit.Record, bool, error) {
+	if err := store.migrate(ctx); err != nil {
+		return audit.Record{}, false, err
+	}
+
+	session := store.session(ctx)
+	if _, ok := transactionFrom(ctx); ok {
+		session = session.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var records []audit.Record
+	if err := session.Table("audit_records").
+		Order("sequence desc").Limit(1).Find(&records).Error; err != nil {
+		return audit.Record{}, false, dbError(ctx, err)
+	}
+
+	if len(records) == 0 {
+		return audit.Record{}, false, nil
+	}
+
+	return records[0], true, nil
+}
+
+// Records reads the table in batches in sequence order.
+func (store *AuditStore) Records(ctx context.Context, fn func(audit.Record) error) error {
+	if err := store.migrate(ctx); err != nil {
+		return err
+	}
+
+	const batchSize = 500
+
+	var after uint64
+
+	for {
+		var records []audit.Record
+		if err := store.dbClient.WithContext(ctx).Table("audit_records").
+			Where("sequence > ?", after).Order("sequence").Limit(batchSize).Find(&records).Error; err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+
+		if len(records) < batchSize {
+			return nil
+		}
+
+		after = records[len(records)-1].Sequence
+	}
+}
+
+// session returns the transaction of the context, or a new session.
+func (store *AuditStore) session(ctx context.Context) *gorm.DB {
+	if tx, ok := transactionFrom(ctx); ok {
+		return tx.WithContext(ctx)
+	}
+
+	return store.dbClient.WithContext(ctx)
+}
+
+// migrate creates the table outside of any transaction, since DDL would
+// implicitly commit it.
+func (store *AuditStore) migrate(ctx context.Context) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.migrated {
+		return nil
+	}
+
+	if err := store.dbClient.WithContext(ctx).Table("audit_records").AutoMigrate(&audit.Record{}); err != nil {
+		return err
+	}
+
+	store.migrated = true
+
+	return nil
+}
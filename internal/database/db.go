@@ -4,18 +4,19 @@ import (
 	"catalogue-app/internal/pkg/log"
 	"catalogue-app/internal/pkg/model"
 	"context"
-	"errors"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type MovieClient struct {
-	dbClient *gorm.DB
+	dbClient     *gorm.DB
+	queryTimeout time.Duration
 }
 
-func NewClient(dbClient *gorm.DB) *MovieClient {
-	return &MovieClient{dbClient: dbClient}
+func NewClient(dbClient *gorm.DB, queryTimeout time.Duration) *MovieClient {
+	return &MovieClient{dbClient: dbClient, queryTimeout: queryTimeout}
 }
 
 type DBCLientIntfc interface {
@@ -24,97 +25,182 @@ type DBCLientIntfc interface {
 	CreateMovie(ctx context.Context, movieInfo model.MovieInfo) (model.MovieInfo, error)
 	UpdateMovie(ctx context.Context, movieInfo model.MovieInfo, movieID string) (model.MovieInfo, error)
 	DeleteMovie(ctx context.Context, movieID string) error
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
-func (movieClient MovieClient) GetMovies(ctx context.Context) ([]model.MovieInfo, error) {
-	movies := []model.MovieInfo{}
+type transactionContextKey struct{}
 
-	if err := movieClient.dbClient.Find(&movies).Error; err != nil {
-		log.Errorf(ctx, "unable to retrieve movies from database")
+// transactionFrom returns the transaction of a context passed to a Transaction function.
+func transactionFrom(ctx context.Context) (*gorm.DB, bool) {
+	tx, ok := ctx.Value(transactionContextKey{}).(*gorm.DB)
+
+	return tx, ok
+}
+
+// withContext binds the gorm session to the request context so that client
+// disconnects and server shutdown cancel running queries, and bounds it by the
+// configured per-operation query timeout. Inside Transaction the session is
+// the transaction.
+func (movieClient MovieClient) withContext(ctx context.Context) (context.Context, *gorm.DB, context.CancelFunc) {
+	cancel := context.CancelFunc(func() {})
+	if movieClient.queryTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, movieClient.queryTimeout)
+	}
 
-		return nil, err
+	if tx, ok := transactionFrom(ctx); ok {
+		return ctx, tx.WithContext(ctx), cancel
 	}
 
-	if len(movies) == 0 {
-		log.Errorf(ctx, "no movies available in the database")
+	return ctx, movieClient.dbClient.WithContext(ctx), cancel
+}
+
+// Transaction runs fn in a transaction, which the movie operations and the
+// audit store take part in when called with the context passed to fn. Movies
+// read in the transaction are locked until it ends. An error of fn rolls the
+// transaction back and is returned as is.
+func (movieClient MovieClient) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	opCtx, dbClient, cancel := movieClient.withContext(ctx)
+	defer cancel()
+
+	var fnErr error
+
+	err := dbClient.Transaction(func(tx *gorm.DB) error {
+		fnErr = fn(context.WithValue(opCtx, transactionContextKey{}, tx))
+
+		return fnErr
+	})
+
+	if fnErr != nil {
+		return fnErr
+	}
+
+	if err != nil {
+		log.Errorf(ctx, "error committing transaction in database")
+
+		return dbError(opCtx, err)
+	}
+
+	return nil
+}
+
+// GetMovies returns all movies, an empty catalogue is an empty list rather
+// than an error.
+func (movieClient MovieClient) GetMovies(ctx context.Context) ([]model.MovieInfo, error) {
+	opCtx, dbClient, cancel := movieClient.withContext(ctx)
+	defer cancel()
+
+	movies := []model.MovieInfo{}
+
+	if err := dbClient.Find(&movies).Error; err != nil {
+		log.Errorf(ctx, "unable to retrieve movies from database")
 
-		return nil, errors.New("no movies found")
+		return nil, dbError(opCtx, err)
 	}
 
 	return movies, nil
 }
 
 func (movieClient MovieClient) GetMovieByID(ctx context.Context, movieID string) (model.MovieInfo, error) {
+	id, err := parseID(movieID)
+	if err != nil {
+		return model.MovieInfo{}, err
+	}
+
+	opCtx, dbClient, cancel := movieClient.withContext(ctx)
+	defer cancel()
+
+	// lock the movie against concurrent changes until the transaction ends
+	if _, ok := transactionFrom(ctx); ok {
+		dbClient = dbClient.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
 	var movie model.MovieInfo
 
-	if err := movieClient.dbClient.First(&movie, movieID).Error; err != nil {
+	if err := dbClient.First(&movie, id).Error; err != nil {
 		log.Errorf(ctx, "unable to retrieve movie for id: %s", movieID)
 
-		return model.MovieInfo{}, err
+		return model.MovieInfo{}, dbError(opCtx, err)
 	}
 
 	return movie, nil
 }
 
 func (movieClient MovieClient) CreateMovie(ctx context.Context, movieInfo model.MovieInfo) (model.MovieInfo, error) {
+	opCtx, dbClient, cancel := movieClient.withContext(ctx)
+	defer cancel()
+
 	movieInfo.CreatedAt = time.Now()
 
-	tx := movieClient.dbClient.Begin()
-	if err := tx.Create(&movieInfo).Error; err != nil {
-		tx.Rollback()
+	err := dbClient.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&movieInfo).Error
+	})
+	if err != nil {
 		log.Errorf(ctx, "error creating movie in database")
 
-		return model.MovieInfo{}, err
+		return model.MovieInfo{}, dbError(opCtx, err)
 	}
 
-	tx.Commit()
-
 	return movieInfo, nil
 }
 
 func (movieClient MovieClient) UpdateMovie(ctx context.Context, movieInfo model.MovieInfo, movieID string) (model.MovieInfo, error) {
+	id, err := parseID(movieID)
+	if err != nil {
+		return model.MovieInfo{}, err
+	}
+
+	opCtx, dbClient, cancel := movieClient.withContext(ctx)
+	defer cancel()
+
 	var movie model.MovieInfo
 
-	if err := movieClient.dbClient.First(&movie, movieID).Error; err != nil {
+	if err := dbClient.First(&movie, id).Error; err != nil {
 		log.Errorf(ctx, "unable to retrieve movie for id: %s", movieID)
 
-		return model.MovieInfo{}, err
+		return model.MovieInfo{}, dbError(opCtx, err)
 	}
 
+	movieInfo.ID = movie.ID
 	movieInfo.CreatedAt = movie.CreatedAt
 	movieInfo.UpdatedAt = time.Now()
 
-	tx := movieClient.dbClient.Begin()
-	if err := tx.Save(&movieInfo).Error; err != nil {
-		tx.Rollback()
+	err = dbClient.Transaction(func(tx *gorm.DB) error {
+		return tx.Save(&movieInfo).Error
+	})
+	if err != nil {
 		log.Errorf(ctx, "error updating movie details in database")
 
-		return model.MovieInfo{}, err
+		return model.MovieInfo{}, dbError(opCtx, err)
 	}
 
-	tx.Commit()
-
 	return movieInfo, nil
 }
 
 func (movieClient MovieClient) DeleteMovie(ctx context.Context, movieID string) error {
+	id, err := parseID(movieID)
+	if err != nil {
+		return err
+	}
+
+	opCtx, dbClient, cancel := movieClient.withContext(ctx)
+	defer cancel()
+
 	var movie model.MovieInfo
 
-	if err := movieClient.dbClient.First(&movie, movieID).Error; err != nil {
+	if err := dbClient.First(&movie, id).Error; err != nil {
 		log.Errorf(ctx, "unable to retrieve movie for id: %s", movieID)
 
-		return err
+		return dbError(opCtx, err)
 	}
 
-	tx := movieClient.dbClient.Begin()
-	if err := tx.Delete(&movie).Error; err != nil {
-		tx.Rollback()
+	err = dbClient.Transaction(func(tx *gorm.DB) error {
+		return tx.Delete(&movie).Error
+	})
+	if err != nil {
 		log.Errorf(ctx, "error deleting movie in database")
 
-		return err
+		return dbError(opCtx, err)
 	}
 
-	tx.Commit()
-
 	return nil
 }
@@ -5,8 +5,9 @@ import (
 	"catalogue-app/internal/pkg/log"
 	"context"
 	"database/sql"
-	"fmt"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -16,6 +17,11 @@ type DBClient struct {
 	dbConfig *config.Configuration
 }
 
+// NewDBClient returns a DBClient connecting with the given configuration.
+func NewDBClient(dbConfig *config.Configuration) DBClient {
+	return DBClient{dbConfig: dbConfig}
+}
+
 func (client DBClient) DBInit() (*gorm.DB, error) {
 	address := client.dbConfig.DBConfig.Url
 
@@ -54,24 +60,29 @@ func (client DBClient) DBInit() (*gorm.DB, error) {
 
 		return nil, err
 	}
-	sqlDB.SetMaxIdleConns(10)   // max number of connections in the idle connection pool
-	sqlDB.SetMaxOpenConns(2)    // max number of open connections in the database
-	sqlDB.SetConnMaxLifetime(1) // max amount of time a connection may be reused
+	sqlDB.SetMaxOpenConns(client.dbConfig.DBConfig.MaxOpenConns)       // max number of open connections in the database
+	sqlDB.SetMaxIdleConns(client.dbConfig.DBConfig.MaxIdleConns)       // max number of connections in the idle connection pool
+	sqlDB.SetConnMaxLifetime(client.dbConfig.DBConfig.ConnMaxLifetime) // max amount of time a connection may be reused
+	sqlDB.SetConnMaxIdleTime(client.dbConfig.DBConfig.ConnMaxIdleTime) // max amount of time a connection may be idle
+
+	// export sql.DB.Stats() on /support/metrics to size the pool from real data
+	if err := prometheus.Register(collectors.NewDBStatsCollector(sqlDB, client.dbConfig.DBConfig.DbName)); err != nil {
+		log.Warnf(context.Background(), "failed registering db pool metrics: %v", err)
+	}
 
+	// The connection is opened lazily so that the service can start before
+	// MySQL is reachable, WaitForDB blocks until it is.
 	dbVal, err := gorm.Open(mysql.New(mysql.Config{
-		Conn: sqlDB,
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
 	}), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.LogLevel(0)),
+		Logger:               logger.Default.LogMode(logger.LogLevel(0)),
+		DisableAutomaticPing: true,
 	})
 	if err != nil {
 		log.Errorf(context.Background(), "failed opening db")
 
 		return nil, err
 	}
-	// Only for debugging
-	if err == nil {
-		fmt.Println("DB connection successful!")
-	}
-
 	return dbVal, nil
 }
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	gerror "catalogue-app/internal/pkg/error"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+// MySQL server error numbers classified by dbError.
+const (
+	mysqlDuplicateEntry  = 1062
+	mysqlLockWaitTimeout = 1205
+	mysqlDeadlock        = 1213
+)
+
+// dbError classifies database errors into error codes: expired deadlines are
+// DBTimeout, missing records NotFound, duplicate keys Conflict, and deadlocks
+// and lock wait timeouts Retryable. Other errors are returned as is.
+func dbError(ctx context.Context, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return gerror.NewFromError(gerror.DBTimeout, err)
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return gerror.NewFromError(gerror.NotFound, err)
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case mysqlDuplicateEntry:
+			return gerror.NewFromError(gerror.Conflict, err)
+		case mysqlDeadlock, mysqlLockWaitTimeout:
+			return gerror.NewFromError(gerror.Retryable, err)
+		}
+	}
+
+	return err
+}
+
+// parseID parses a movie ID, malformed IDs are BadRequest.
+func parseID(movieID string) (int64, error) {
+	id, err := strconv.ParseInt(movieID, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, gerror.Newf(gerror.BadRequest, "invalid movie id %q", movieID)
+	}
+
+	return id, nil
+}
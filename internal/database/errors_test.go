@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"catalogue-app/internal/pkg/audit"
+	gerror "catalogue-app/internal/pkg/error"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestDBError(t *testing.T) {
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		code gerror.ErrorCode
+	}{
+		{name: "deadline exceeded", ctx: context.Background(), err: context.DeadlineExceeded, code: gerror.DBTimeout},
+		{name: "expired context", ctx: expired, err: errors.New("driver: bad connection"), code: gerror.DBTimeout},
+		{name: "record not found", ctx: context.Background(), err: gorm.ErrRecordNotFound, code: gerror.NotFound},
+		{name: "duplicate entry", ctx: context.Background(), err: &mysql.MySQLError{Number: mysqlDuplicateEntry}, code: gerror.Conflict},
+		{name: "deadlock", ctx: context.Background(), err: &mysql.MySQLError{Number: mysqlDeadlock}, code: gerror.Retryable},
+		{name: "lock wait timeout", ctx: context.Background(), err: &mysql.MySQLError{Number: mysqlLockWaitTimeout}, code: gerror.Retryable},
+		{name: "other mysql error", ctx: context.Background(), err: &mysql.MySQLError{Number: 1146}, code: gerror.InternalError},
+		{name: "other error", ctx: context.Background(), err: errors.New("connection refused"), code: gerror.InternalError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := dbError(test.ctx, test.err)
+			if code := gerror.GetErrorType(err); code != test.code {
+				t.Errorf("got %s, want %s", code, test.code)
+			}
+
+			if !errors.Is(err, test.err) {
+				t.Errorf("%v doesn't wrap %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestParseID(t *testing.T) {
+	if id, err := parseID("42"); id != 42 || err != nil {
+		t.Errorf("got %d, %v, want 42", id, err)
+	}
+
+	for _, movieID := range []string{"", "0", "-1", "4x", "99999999999999999999"} {
+		if _, err := parseID(movieID); gerror.GetErrorType(err) != gerror.BadRequest {
+			t.Errorf("parseID(%q) returned %v, want BadRequest", movieID, err)
+		}
+	}
+}
+
+// racingStore fails appends the way AuditStore does when another instance
+// appended the same sequence number first.
+type racingStore struct{}
+
+func (racingStore) Append(ctx context.Context, record audit.Record) error {
+	return dbError(ctx, &mysql.MySQLError{Number: mysqlDuplicateEntry, Message: "Duplicate entry for key 'PRIMARY'"})
+}
+
+func (racingStore) Last(context.Context) (audit.Record, bool, error) {
+	return audit.Record{Sequence: 4, Hash: "hash"}, true, nil
+}
+
+func (racingStore) Records(context.Context, func(audit.Record) error) error {
+	return nil
+}
+
+func TestDuplicateAuditSequenceConflict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	chain := audit.NewChain(racingStore{}, nil, 0)
+
+	err := chain.Record(context.Background(), audit.Entry{Action: "create", Resource: "movie", ResourceID: "5"})
+	if err == nil {
+		t.Fatal("Record succeeded with a duplicate sequence")
+	}
+
+	recorder := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(recorder)
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/catalogue/movies", nil)
+
+	gerror.RespondWithError(ginCtx, err, "")
+
+	if recorder.Code != http.StatusConflict {
+		t.Errorf("got status %d, want %d", recorder.Code, http.StatusConflict)
+	}
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"catalogue-app/internal/pkg/health"
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// schemaMigration mirrors the golang-migrate bookkeeping table.
+type schemaMigration struct {
+	Version uint
+	Dirty   bool
+}
+
+// PingCheck returns a health check pinging the database, it also fails when
+// the connection pool is exhausted for longer than the check timeout.
+func PingCheck(dbVal *gorm.DB) health.CheckFunc {
+	return func(ctx context.Context) error {
+		sqlDB, err := dbVal.DB()
+		if err != nil {
+			return err
+		}
+
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// SchemaVersionCheck returns a health check failing when the applied migration
+// version is dirty or behind minVersion.
+func SchemaVersionCheck(dbVal *gorm.DB, minVersion uint) health.CheckFunc {
+	return func(ctx context.Context) error {
+		var migration schemaMigration
+
+		err := dbVal.WithContext(ctx).Table("schema_migrations").Take(&migration).Error
+		if err != nil {
+			return fmt.Errorf("unable to read migration version: %w", err)
+		}
+
+		if migration.Dirty {
+			return fmt.Errorf("migration version %d is dirty", migration.Version)
+		}
+
+		if migration.Version < minVersion {
+			return fmt.Errorf("migration version %d is behind required version %d", migration.Version, minVersion)
+		}
+
+		return nil
+	}
+}
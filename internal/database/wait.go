@@ -0,0 +1,73 @@
+package db
+
+import (
+	"catalogue-app/internal/pkg/log"
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WaitForDB pings the database until it is reachable, backing off exponentially
+// with jitter between attempts. It gives up once the configured ConnectTimeout
+// elapses or ctx is done.
+func (client DBClient) WaitForDB(ctx context.Context, dbVal *gorm.DB) error {
+	sqlDB, err := dbVal.DB()
+	if err != nil {
+		return err
+	}
+
+	dbConfig := client.dbConfig.DBConfig
+
+	ctx, cancel := context.WithTimeout(ctx, dbConfig.ConnectTimeout)
+	defer cancel()
+
+	backoff := dbConfig.ConnectBackoffStart
+
+	for attempt := 1; ; attempt++ {
+		err = ping(ctx, sqlDB.PingContext, dbConfig.QueryTimeout)
+		if err == nil {
+			log.Infof(ctx, "database reachable after %d attempt(s)", attempt)
+
+			return nil
+		}
+
+		delay := jitter(backoff)
+		log.Warnf(ctx, "database not reachable (attempt %d), retrying in %s: %v", attempt, delay, err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database not reachable after %d attempt(s): %w", attempt, err)
+		case <-time.After(delay):
+		}
+
+		backoff *= 2
+		if backoff > dbConfig.ConnectBackoffMax {
+			backoff = dbConfig.ConnectBackoffMax
+		}
+	}
+}
+
+// ping runs a single ping bounded by timeout, zero means no additional bound.
+func ping(ctx context.Context, pingFn func(context.Context) error, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	return pingFn(ctx)
+}
+
+// jitter returns a random delay in [backoff/2, backoff) so that replicas
+// starting together don't retry in lockstep.
+func jitter(backoff time.Duration) time.Duration {
+	half := backoff / 2
+	if half <= 0 {
+		return backoff
+	}
+
+	return half + time.Duration(rand.Int63n(int64(half)))
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"catalogue-app/internal/controller"
+	gerror "catalogue-app/internal/pkg/error"
 	"catalogue-app/internal/pkg/model"
 	"net/http"
 
@@ -19,7 +20,7 @@ func NewMovieHandler(dbController controller.ControllerIntfc) *MovieHandler {
 func (handler MovieHandler) GetMovies(ginCtx *gin.Context) {
 	result, err := handler.dbController.GetMovies(ginCtx.Request.Context())
 	if err != nil {
-		ginCtx.AbortWithError(http.StatusNotFound, err)
+		respondWithError(ginCtx, err)
 		return
 	}
 
@@ -31,7 +32,7 @@ func (handler MovieHandler) GetMovieByID(ginCtx *gin.Context) {
 
 	result, err := handler.dbController.GetMovieByID(ginCtx.Request.Context(), id)
 	if err != nil {
-		ginCtx.AbortWithError(http.StatusNotFound, err)
+		respondWithError(ginCtx, err)
 		return
 	}
 
@@ -41,14 +42,14 @@ func (handler MovieHandler) GetMovieByID(ginCtx *gin.Context) {
 func (handler MovieHandler) CreateMovie(ginCtx *gin.Context) {
 	var movieInfo model.MovieInfo
 
-	if err := ginCtx.BindJSON(&movieInfo); err != nil {
-		ginCtx.AbortWithError(http.StatusBadRequest, err)
+	if err := ginCtx.ShouldBindJSON(&movieInfo); err != nil {
+		respondWithError(ginCtx, gerror.NewFromError(gerror.FailedUnmarshalling, err))
 		return
 	}
 
 	result, err := handler.dbController.CreateMovie(ginCtx.Request.Context(), movieInfo)
 	if err != nil {
-		ginCtx.AbortWithError(http.StatusInternalServerError, err)
+		respondWithError(ginCtx, err)
 		return
 	}
 
@@ -60,14 +61,33 @@ func (handler MovieHandler) UpdateMovie(ginCtx *gin.Context) {
 
 	var movieInfo model.MovieInfo
 
-	if err := ginCtx.BindJSON(&movieInfo); err != nil {
-		ginCtx.AbortWithError(http.StatusBadRequest, err)
+	if err := ginCtx.ShouldBindJSON(&movieInfo); err != nil {
+		respondWithError(ginCtx, gerror.NewFromError(gerror.FailedUnmarshalling, err))
 		return
 	}
 
 	result, err := handler.dbController.UpdateMovie(ginCtx.Request.Context(), movieInfo, id)
 	if err != nil {
-		ginCtx.AbortWithError(http.StatusInternalServerError, err)
+		respondWithError(ginCtx, err)
+		return
+	}
+
+	ginCtx.JSON(http.StatusOK, &result)
+}
+
+func (handler MovieHandler) PatchMovie(ginCtx *gin.Context) {
+	id := ginCtx.Param("id")
+
+	var patch model.MoviePatch
+
+	if err := ginCtx.ShouldBindJSON(&patch); err != nil {
+		respondWithError(ginCtx, gerror.NewFromError(gerror.FailedUnmarshalling, err))
+		return
+	}
+
+	result, err := handler.dbController.PatchMovie(ginCtx.Request.Context(), patch, id)
+	if err != nil {
+		respondWithError(ginCtx, err)
 		return
 	}
 
@@ -79,9 +99,16 @@ func (handler MovieHandler) DeleteMovie(ginCtx *gin.Context) {
 
 	err := handler.dbController.DeleteMovie(ginCtx.Request.Context(), id)
 	if err != nil {
-		ginCtx.AbortWithError(http.StatusNotFound, err)
+		respondWithError(ginCtx, err)
 		return
 	}
 
 	ginCtx.JSON(http.StatusNoContent, gin.H{"status": "deleted"})
 }
+
+// respondWithError aborts the request with the error response of err, the
+// database layer classifies its errors so that the status matches the failure.
+func respondWithError(ginCtx *gin.Context, err error) {
+	gerror.RespondWithError(ginCtx, err, "")
+	ginCtx.Abort()
+}
@@ -0,0 +1,256 @@
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"catalogue-app/internal/pkg/log"
+)
+
+// anonymous is the actor of requests without an authenticated user.
+const anonymous = "anonymous"
+
+// Record is a single link of the audit chain. Hash covers every other field,
+// including the hash of the previous record, so that editing, inserting or
+// removing a record breaks the chain. Every SignEvery-th record carries an HMAC
+// of its hash, which a writer without the key can't forge.
+type Record struct {
+	Sequence   uint64    `gorm:"primaryKey;autoIncrement:false" json:"sequence"`
+	Time       time.Time `json:"time"`
+	Actor      string    `json:"actor"`
+	Action     string    `json:"action"`
+	Resource   string    `json:"resource"`
+	ResourceID string    `json:"resourceID"`
+	RequestID  string    `json:"requestID,omitempty"`
+	TenantID   string    `json:"tenantID,omitempty"`
+	Diff       string    `json:"diff,omitempty"`
+	PrevHash   string    `json:"prevHash"`
+	Hash       string    `json:"hash"`
+	Signature  string    `json:"signature,omitempty"`
+}
+
+// Change is the old and new value of a changed field.
+type Change struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// Entry describes an audited action, Before is nil for creations and After
+// is nil for deletions.
+type Entry struct {
+	Action     string
+	Resource   string
+	ResourceID string
+	Before     interface{}
+	After      interface{}
+}
+
+// Recorder records audited actions.
+type Recorder interface {
+	Record(ctx context.Context, entry Entry) error
+}
+
+// Store persists the chain in sequence order.
+type Store interface {
+	Append(ctx context.Context, record Record) error
+	// Last returns the record with the highest sequence number, false if there
+	// is none. It is called for every appended record.
+	Last(ctx context.Context) (Record, bool, error)
+	// Records calls fn for every record in sequence order.
+	Records(ctx context.Context, fn func(Record) error) error
+}
+
+// LogRecorder writes audited actions to the audit log stream only.
+type LogRecorder struct{}
+
+// Record writes the entry to the audit log stream.
+func (LogRecorder) Record(ctx context.Context, entry Entry) error {
+	diff, err := Diff(entry.Before, entry.After)
+	if err != nil {
+		return err
+	}
+
+	logRecord(ctx, Record{
+		Actor:      actor(ctx),
+		Action:     entry.Action,
+		Resource:   entry.Resource,
+		ResourceID: entry.ResourceID,
+		Diff:       diff,
+	})
+
+	return nil
+}
+
+// Chain appends audited actions to a hash chain in a Store and to the audit log
+// stream. The last record is read from the store for every record, so that a
+// record rolled back with the audited change doesn't break the chain, and the
+// store needn't be reachable at startup.
+type Chain struct {
+	mutex     sync.Mutex
+	store     Store
+	key       []byte
+	signEvery uint64
+}
+
+// NewChain returns a Chain appending to store. If key is set, every
+// signEvery-th record is signed with it.
+func NewChain(store Store, key []byte, signEvery uint64) *Chain {
+	return &Chain{store: store, key: key, signEvery: signEvery}
+}
+
+// Record appends the entry to the chain, with the context of the audited
+// change so that a transactional store takes part in its transaction.
+func (chain *Chain) Record(ctx context.Context, entry Entry) error {
+	diff, err := Diff(entry.Before, entry.After)
+	if err != nil {
+		return err
+	}
+
+	chain.mutex.Lock()
+	defer chain.mutex.Unlock()
+
+	last, hasLast, err := chain.store.Last(ctx)
+	if err != nil {
+		return fmt.Errorf("audit chain failed loading last record %w", err)
+	}
+
+	record := Record{
+		Sequence:   1,
+		Time:       time.Now().UTC().Truncate(time.Millisecond),
+		Actor:      actor(ctx),
+		Action:     entry.Action,
+		Resource:   entry.Resource,
+		ResourceID: entry.ResourceID,
+		RequestID:  log.RequestID(ctx),
+		TenantID:   log.TenantID(ctx),
+		Diff:       diff,
+	}
+
+	if hasLast {
+		record.Sequence = last.Sequence + 1
+		record.PrevHash = last.Hash
+	}
+
+	if record.Hash, err = hash(record); err != nil {
+		return err
+	}
+
+	if len(chain.key) > 0 && record.Sequence%chain.signEvery == 0 {
+		record.Signature = sign(chain.key, record.Hash)
+	}
+
+	if err := chain.store.Append(ctx, record); err != nil {
+		return fmt.Errorf("audit chain failed appending record %d %w", record.Sequence, err)
+	}
+
+	logRecord(ctx, record)
+
+	return nil
+}
+
+// Diff returns the JSON object of the fields that differ between before and
+// after, each with its old and new value.
+func Diff(before interface{}, after interface{}) (string, error) {
+	oldFields, err := fields(before)
+	if err != nil {
+		return "", err
+	}
+
+	newFields, err := fields(after)
+	if err != nil {
+		return "", err
+	}
+
+	changes := make(map[string]Change)
+
+	for key, old := range oldFields {
+		if value, ok := newFields[key]; !ok || !reflect.DeepEqual(old, value) {
+			changes[key] = Change{Old: old, New: value}
+		}
+	}
+
+	for key, value := range newFields {
+		if _, ok := oldFields[key]; !ok {
+			changes[key] = Change{New: value}
+		}
+	}
+
+	if len(changes) == 0 {
+		return "", nil
+	}
+
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func fields(value interface{}) (map[string]interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("audit diff needs an object %w", err)
+	}
+
+	return fields, nil
+}
+
+// hash returns the SHA-256 of the record without its hash and signature, the
+// time is normalized so that the hash survives a database round trip.
+func hash(record Record) (string, error) {
+	record.Time = record.Time.UTC()
+	record.Hash = ""
+	record.Signature = ""
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func sign(key []byte, recordHash string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(recordHash))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func actor(ctx context.Context) string {
+	if userID := log.UserID(ctx); userID != "" {
+		return userID
+	}
+
+	return anonymous
+}
+
+func logRecord(ctx context.Context, record Record) {
+	log.Auditw(ctx, "audit record",
+		log.Any("sequence", record.Sequence),
+		log.String("actor", record.Actor),
+		log.String("action", record.Action),
+		log.String("resource", record.Resource),
+		log.String("resourceID", record.ResourceID),
+		log.String("diff", record.Diff),
+		log.String("hash", record.Hash))
+}
@@ -0,0 +1,320 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"catalogue-app/internal/pkg/log"
+)
+
+type movie struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	Year  int    `json:"year"`
+}
+
+func newFileStore(t *testing.T) *FileStore {
+	t.Helper()
+
+	return NewFileStore(filepath.Join(t.TempDir(), "audit", "audit.log"))
+}
+
+// recordMovie records the creation, update and deletion of a movie.
+func recordMovie(t *testing.T, chain *Chain) {
+	t.Helper()
+
+	ctx := log.WithUserID(context.Background(), "alice")
+	created := movie{ID: 7, Title: "Alien", Year: 1978}
+	updated := movie{ID: 7, Title: "Alien", Year: 1979}
+
+	entries := []Entry{
+		{Action: "create", Resource: "movie", ResourceID: "7", After: created},
+		{Action: "update", Resource: "movie", ResourceID: "7", Before: created, After: updated},
+		{Action: "delete", Resource: "movie", ResourceID: "7", Before: updated},
+	}
+
+	for _, entry := range entries {
+		if err := chain.Record(ctx, entry); err != nil {
+			t.Fatalf("Record %s failed: %v", entry.Action, err)
+		}
+	}
+}
+
+func readRecords(t *testing.T, store Store) []Record {
+	t.Helper()
+
+	var records []Record
+
+	err := store.Records(context.Background(), func(record Record) error {
+		records = append(records, record)
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Records failed: %v", err)
+	}
+
+	return records
+}
+
+// rewrite replaces the file of store with the given lines.
+func rewrite(t *testing.T, store *FileStore, lines []string) {
+	t.Helper()
+
+	if err := os.WriteFile(store.path, []byte(strings.Join(lines, "\n")+"\n"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readLines(t *testing.T, store *FileStore) []string {
+	t.Helper()
+
+	data, err := os.ReadFile(store.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func TestChainRecord(t *testing.T) {
+	store := newFileStore(t)
+	recordMovie(t, NewChain(store, nil, 0))
+
+	records := readRecords(t, store)
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3", len(records))
+	}
+
+	for i, record := range records {
+		if record.Sequence != uint64(i+1) {
+			t.Errorf("record %d has sequence %d", i+1, record.Sequence)
+		}
+
+		if i > 0 && record.PrevHash != records[i-1].Hash {
+			t.Errorf("record %d doesn't link to the hash of record %d", record.Sequence, i)
+		}
+
+		if record.Actor != "alice" || record.Resource != "movie" || record.ResourceID != "7" {
+			t.Errorf("record %d is %+v", record.Sequence, record)
+		}
+	}
+
+	if records[0].PrevHash != "" {
+		t.Errorf("first record links to %q", records[0].PrevHash)
+	}
+
+	var diff map[string]Change
+	if err := json.Unmarshal([]byte(records[1].Diff), &diff); err != nil {
+		t.Fatalf("update diff %q is not JSON: %v", records[1].Diff, err)
+	}
+
+	if len(diff) != 1 || diff["year"].Old != float64(1978) || diff["year"].New != float64(1979) {
+		t.Errorf("got update diff %v, want only year 1978 to 1979", diff)
+	}
+
+	if count, err := Verify(context.Background(), store, nil, 0); count != 3 || err != nil {
+		t.Errorf("Verify returned %d, %v, want 3 records", count, err)
+	}
+}
+
+func TestChainContinuesAfterRestart(t *testing.T) {
+	store := newFileStore(t)
+	recordMovie(t, NewChain(store, nil, 0))
+
+	// a new store reads the last record from the file
+	reopened := NewFileStore(store.path)
+	recordMovie(t, NewChain(reopened, nil, 0))
+
+	if count, err := Verify(context.Background(), reopened, nil, 0); count != 6 || err != nil {
+		t.Errorf("Verify returned %d, %v, want 6 records", count, err)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name    string
+		tamper  func(lines []string) []string
+		count   uint64
+		message string
+	}{
+		{
+			name: "edited record",
+			tamper: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], `"actor":"alice"`, `"actor":"mallory"`, 1)
+
+				return lines
+			},
+			count:   1,
+			message: "record 2 was edited",
+		},
+		{
+			name: "deleted record",
+			tamper: func(lines []string) []string {
+				return append(lines[:1], lines[2:]...)
+			},
+			count:   1,
+			message: "gap before record 3",
+		},
+		{
+			name: "reordered records",
+			tamper: func(lines []string) []string {
+				lines[1], lines[2] = lines[2], lines[1]
+
+				return lines
+			},
+			count:   1,
+			message: "gap before record 3",
+		},
+		{
+			name: "record replaced with a new chain",
+			tamper: func(lines []string) []string {
+				var record Record
+				if err := json.Unmarshal([]byte(lines[1]), &record); err != nil {
+					panic(err)
+				}
+
+				// a consistent hash, but not linked to the first record
+				record.PrevHash = ""
+				record.Hash, _ = hash(record)
+				data, _ := json.Marshal(record)
+				lines[1] = string(data)
+
+				return lines
+			},
+			count:   1,
+			message: "record 2 doesn't link to record 1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := newFileStore(t)
+			recordMovie(t, NewChain(store, nil, 0))
+
+			rewrite(t, store, test.tamper(readLines(t, store)))
+
+			count, err := Verify(context.Background(), store, nil, 0)
+			if err == nil || !strings.Contains(err.Error(), test.message) {
+				t.Fatalf("got error %v, want %q", err, test.message)
+			}
+
+			if count != test.count {
+				t.Errorf("got %d verified records, want %d", count, test.count)
+			}
+		})
+	}
+}
+
+func TestChainSigning(t *testing.T) {
+	key := []byte("audit-signing-key")
+	store := newFileStore(t)
+	chain := NewChain(store, key, 2)
+
+	recordMovie(t, chain)
+	recordMovie(t, chain)
+
+	records := readRecords(t, store)
+	for _, record := range records {
+		if signed := record.Signature != ""; signed != (record.Sequence%2 == 0) {
+			t.Errorf("record %d signed %v", record.Sequence, signed)
+		}
+	}
+
+	if count, err := Verify(context.Background(), store, key, 2); count != 6 || err != nil {
+		t.Errorf("Verify returned %d, %v, want 6 records", count, err)
+	}
+
+	if _, err := Verify(context.Background(), store, []byte("other-key"), 2); err == nil ||
+		!strings.Contains(err.Error(), "record 2 has a missing or invalid signature") {
+		t.Errorf("got error %v for the wrong key", err)
+	}
+
+	// a writer without the key can rebuild the hashes, but not the signatures
+	lines := readLines(t, store)
+	prevHash := ""
+
+	for i, line := range lines {
+		var record Record
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+
+		record.Actor = "mallory"
+		record.PrevHash = prevHash
+		record.Hash, _ = hash(record)
+		prevHash = record.Hash
+
+		data, _ := json.Marshal(record)
+		lines[i] = string(data)
+	}
+
+	rewrite(t, store, lines)
+
+	if _, err := Verify(context.Background(), store, nil, 2); err != nil {
+		t.Errorf("Verify without key failed on a consistent chain: %v", err)
+	}
+
+	if _, err := Verify(context.Background(), store, key, 2); err == nil ||
+		!strings.Contains(err.Error(), "record 2 has a missing or invalid signature") {
+		t.Errorf("got error %v for a rebuilt chain", err)
+	}
+}
+
+func TestChainConcurrentRecord(t *testing.T) {
+	const writers = 50
+
+	store := newFileStore(t)
+	chain := NewChain(store, []byte("audit-signing-key"), 10)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := chain.Record(context.Background(), Entry{Action: "create", Resource: "movie", After: movie{Title: "Alien"}}); err != nil {
+				t.Errorf("Record failed: %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if count, err := Verify(context.Background(), store, []byte("audit-signing-key"), 10); count != writers || err != nil {
+		t.Errorf("Verify returned %d, %v, want %d records", count, err, writers)
+	}
+}
+
+func TestFileStoreRecords(t *testing.T) {
+	store := newFileStore(t)
+
+	if records := readRecords(t, store); len(records) != 0 {
+		t.Errorf("got %d records from a missing file", len(records))
+	}
+
+	if _, found, err := store.Last(context.Background()); found || err != nil {
+		t.Errorf("got found %v error %v from a missing file", found, err)
+	}
+
+	recordMovie(t, NewChain(store, nil, 0))
+
+	lines := readLines(t, store)
+	rewrite(t, store, append(lines, "not a record"))
+
+	err := NewFileStore(store.path).Records(context.Background(), func(Record) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "line 4 is not an audit record") {
+		t.Errorf("got error %v, want line 4 rejected", err)
+	}
+
+	if _, _, err := NewFileStore(store.path).Last(context.Background()); err == nil {
+		t.Error("Last succeeded on a corrupt file")
+	}
+}
@@ -0,0 +1,132 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileStore keeps the chain in a JSON lines file, it must only be written by
+// one process. Records are appended before the audited change commits, so a
+// failed commit leaves the record of an attempted change.
+type FileStore struct {
+	path string
+
+	// last caches the last record of the file once it has been read.
+	mutex   sync.Mutex
+	last    Record
+	hasLast bool
+	loaded  bool
+}
+
+// NewFileStore returns a FileStore for path, the file is created on the first append.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Append writes the record and syncs the file.
+func (store *FileStore) Append(_ context.Context, record Record) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if err := store.append(record); err != nil {
+		return err
+	}
+
+	store.last, store.hasLast = record, true
+
+	return nil
+}
+
+func (store *FileStore) append(record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(store.path), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(store.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+
+		return err
+	}
+
+	return file.Close()
+}
+
+// Last returns the last record of the file, which is only read once.
+func (store *FileStore) Last(ctx context.Context) (Record, bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.loaded {
+		return store.last, store.hasLast, nil
+	}
+
+	err := store.Records(ctx, func(record Record) error {
+		store.last, store.hasLast = record, true
+
+		return nil
+	})
+	if err != nil {
+		store.last, store.hasLast = Record{}, false
+
+		return Record{}, false, err
+	}
+
+	store.loaded = true
+
+	return store.last, store.hasLast, nil
+}
+
+// Records reads the file line by line, a missing file has no records.
+func (store *FileStore) Records(ctx context.Context, fn func(Record) error) error {
+	file, err := os.Open(store.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("line %d is not an audit record %v", line, err)
+		}
+
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
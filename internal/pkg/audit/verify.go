@@ -0,0 +1,49 @@
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"fmt"
+)
+
+// Verify walks the chain in store and returns the number of records verified,
+// with an error naming the first gap, edit or missing signature. Signatures are
+// only checked if key is set.
+func Verify(ctx context.Context, store Store, key []byte, signEvery uint64) (uint64, error) {
+	var (
+		count    uint64
+		prevHash string
+	)
+
+	err := store.Records(ctx, func(record Record) error {
+		if record.Sequence != count+1 {
+			return fmt.Errorf("gap before record %d, expected sequence %d", record.Sequence, count+1)
+		}
+
+		if record.PrevHash != prevHash {
+			return fmt.Errorf("record %d doesn't link to record %d", record.Sequence, record.Sequence-1)
+		}
+
+		recordHash, err := hash(record)
+		if err != nil {
+			return err
+		}
+
+		if recordHash != record.Hash {
+			return fmt.Errorf("record %d was edited, its hash doesn't match", record.Sequence)
+		}
+
+		if len(key) > 0 && record.Sequence%signEvery == 0 {
+			if !hmac.Equal([]byte(record.Signature), []byte(sign(key, record.Hash))) {
+				return fmt.Errorf("record %d has a missing or invalid signature", record.Sequence)
+			}
+		}
+
+		prevHash = record.Hash
+		count++
+
+		return nil
+	})
+
+	return count, err
+}
@@ -0,0 +1,128 @@
+package crash
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"catalogue-app/internal/pkg/requestid"
+)
+
+// Report describes a recovered panic. Fingerprint identifies the panic site
+// by its stack, so that repeated crashes can be deduplicated.
+type Report struct {
+	Fingerprint string    `json:"fingerprint"`
+	Time        time.Time `json:"time"`
+	RequestID   string    `json:"requestID,omitempty"`
+	Method      string    `json:"method,omitempty"`
+	Path        string    `json:"path,omitempty"`
+	Panic       string    `json:"panic"`
+	Stack       string    `json:"stack"`
+}
+
+// Sink receives crash reports, such as an error reporting service.
+type Sink interface {
+	Report(ctx context.Context, report Report) error
+}
+
+// NewReport returns the report of a panic, call it from the deferred function
+// that recovered so that the stack includes the panic site.
+func NewReport(ctx context.Context, recovered interface{}, method string, path string) Report {
+	var pcs [64]uintptr
+	// skip runtime.Callers and NewReport
+	n := runtime.Callers(2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var (
+		stack strings.Builder
+		sites strings.Builder
+	)
+
+	for {
+		frame, more := frames.Next()
+
+		fmt.Fprintf(&stack, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		// lines are left out of the fingerprint so that unrelated edits don't split it
+		if !strings.HasPrefix(frame.Function, "runtime.") {
+			fmt.Fprintf(&sites, "%s\n", frame.Function)
+		}
+
+		if !more {
+			break
+		}
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%T\n%s", recovered, sites.String())))
+
+	return Report{
+		Fingerprint: hex.EncodeToString(sum[:8]),
+		Time:        time.Now().UTC(),
+		RequestID:   requestid.FromContext(ctx),
+		Method:      method,
+		Path:        path,
+		Panic:       fmt.Sprint(recovered),
+		Stack:       stack.String(),
+	}
+}
+
+// redactingSink masks the panic value and stack before forwarding a report.
+type redactingSink struct {
+	sink   Sink
+	redact func(string) string
+}
+
+// Redact returns a Sink passing the panic value and stack of a report through
+// redact before forwarding it to sink, since both may hold request data.
+func Redact(sink Sink, redact func(string) string) Sink {
+	return redactingSink{sink: sink, redact: redact}
+}
+
+// Report forwards the redacted report.
+func (redacting redactingSink) Report(ctx context.Context, report Report) error {
+	report.Panic = redacting.redact(report.Panic)
+	report.Stack = redacting.redact(report.Stack)
+
+	return redacting.sink.Report(ctx, report)
+}
+
+// deduplicator forwards the first report of every fingerprint per window.
+type deduplicator struct {
+	sink   Sink
+	window time.Duration
+
+	mutex    sync.Mutex
+	reported map[string]time.Time
+}
+
+// Deduplicate returns a Sink forwarding a fingerprint to sink at most once per window.
+func Deduplicate(sink Sink, window time.Duration) Sink {
+	return &deduplicator{sink: sink, window: window, reported: make(map[string]time.Time)}
+}
+
+// Report forwards the report unless its fingerprint was reported within the window.
+func (dedup *deduplicator) Report(ctx context.Context, report Report) error {
+	dedup.mutex.Lock()
+
+	now := time.Now()
+	for fingerprint, reportedAt := range dedup.reported {
+		if now.Sub(reportedAt) >= dedup.window {
+			delete(dedup.reported, fingerprint)
+		}
+	}
+
+	if _, ok := dedup.reported[report.Fingerprint]; ok {
+		dedup.mutex.Unlock()
+
+		return nil
+	}
+
+	dedup.reported[report.Fingerprint] = now
+	dedup.mutex.Unlock()
+
+	return dedup.sink.Report(ctx, report)
+}
@@ -0,0 +1,63 @@
+package crash
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingSink keeps the reports it receives.
+type recordingSink struct {
+	reports []Report
+}
+
+func (sink *recordingSink) Report(_ context.Context, report Report) error {
+	sink.reports = append(sink.reports, report)
+
+	return nil
+}
+
+func TestRedact(t *testing.T) {
+	const secret = "jane.doe@example.com"
+
+	recording := &recordingSink{}
+	sink := Redact(recording, func(s string) string {
+		return strings.ReplaceAll(s, secret, "[REDACTED]")
+	})
+
+	report := NewReport(context.Background(), "no movie for "+secret, "GET", "/movies/:id")
+	report.Stack += "argument " + secret
+
+	if err := sink.Report(context.Background(), report); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(recording.reports) != 1 {
+		t.Fatalf("sink got %d reports, want 1", len(recording.reports))
+	}
+
+	got := recording.reports[0]
+	if strings.Contains(got.Panic, secret) || strings.Contains(got.Stack, secret) {
+		t.Errorf("report was not redacted: %+v", got)
+	}
+
+	if got.Fingerprint != report.Fingerprint || got.Path != report.Path {
+		t.Errorf("redaction changed the report: %+v", got)
+	}
+}
+
+func TestDeduplicate(t *testing.T) {
+	recording := &recordingSink{}
+	sink := Deduplicate(recording, time.Hour)
+
+	for _, fingerprint := range []string{"a", "b", "a", "b", "a"} {
+		if err := sink.Report(context.Background(), Report{Fingerprint: fingerprint}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(recording.reports) != 2 {
+		t.Errorf("sink got %d reports, want one per fingerprint", len(recording.reports))
+	}
+}
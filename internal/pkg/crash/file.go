@@ -0,0 +1,68 @@
+package crash
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileSink keeps one report file per fingerprint in a directory, repeated
+// crashes update the count and last occurrence of the existing file.
+type FileSink struct {
+	dir   string
+	mutex sync.Mutex
+}
+
+// fileReport is the report of the first occurrence with the repeat count.
+type fileReport struct {
+	Report
+	Count    int       `json:"count"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// NewFileSink returns a FileSink writing to dir, which is created on the first report.
+func NewFileSink(dir string) *FileSink {
+	return &FileSink{dir: dir}
+}
+
+// Report writes or updates the file of the report fingerprint.
+func (sink *FileSink) Report(_ context.Context, report Report) error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+
+	if err := os.MkdirAll(sink.dir, 0o750); err != nil {
+		return err
+	}
+
+	path := filepath.Join(sink.dir, report.Fingerprint+".json")
+	stored := fileReport{Report: report}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &stored); err != nil {
+			stored = fileReport{Report: report}
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return err
+	}
+
+	stored.Count++
+	stored.LastSeen = report.Time
+
+	if data, err = json.MarshalIndent(stored, "", "  "); err != nil {
+		return err
+	}
+
+	// write and rename so that a crash while writing doesn't truncate the report
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o640); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,79 @@
+package error
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var catalogMutex sync.RWMutex
+
+// Register adds error codes to the catalog, so that RespondWithError maps them
+// to their status and message. Registering a code twice is an error.
+func Register(infos ...GLCResponseError) error {
+	catalogMutex.Lock()
+	defer catalogMutex.Unlock()
+
+	seen := make(map[ErrorCode]bool, len(infos))
+
+	for _, info := range infos {
+		if info.ErrorCode == "" {
+			return fmt.Errorf("error code registration without a code")
+		}
+
+		if _, exists := errInfoMap[info.ErrorCode]; exists || seen[info.ErrorCode] {
+			return fmt.Errorf("error code %s is already registered", info.ErrorCode)
+		}
+
+		seen[info.ErrorCode] = true
+
+		if info.HTTPStatusCode < 400 || info.HTTPStatusCode > 599 {
+			return fmt.Errorf("error code %s has status %d, must be between 400 and 599", info.ErrorCode, info.HTTPStatusCode)
+		}
+	}
+
+	for _, info := range infos {
+		info := info
+		errInfoMap[info.ErrorCode] = &info
+	}
+
+	return nil
+}
+
+// MustRegister is Register for package init functions, it panics so that
+// duplicate codes are detected when the service starts.
+func MustRegister(infos ...GLCResponseError) {
+	if err := Register(infos...); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup returns the catalog entry of code.
+func Lookup(code ErrorCode) (GLCResponseError, bool) {
+	catalogMutex.RLock()
+	defer catalogMutex.RUnlock()
+
+	info, ok := errInfoMap[code]
+	if !ok {
+		return GLCResponseError{}, false
+	}
+
+	return *info, true
+}
+
+// Catalog returns every registered error code sorted by code.
+func Catalog() []GLCResponseError {
+	catalogMutex.RLock()
+	defer catalogMutex.RUnlock()
+
+	infos := make([]GLCResponseError, 0, len(errInfoMap))
+	for _, info := range errInfoMap {
+		infos = append(infos, *info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ErrorCode < infos[j].ErrorCode
+	})
+
+	return infos
+}
@@ -0,0 +1,43 @@
+package error
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	first := GLCResponseError{ErrorCode: "TEST_REGISTER_FIRST", HTTPStatusCode: http.StatusTeapot}
+	second := GLCResponseError{ErrorCode: "TEST_REGISTER_SECOND", HTTPStatusCode: http.StatusTeapot}
+
+	t.Cleanup(func() {
+		catalogMutex.Lock()
+		defer catalogMutex.Unlock()
+
+		delete(errInfoMap, first.ErrorCode)
+		delete(errInfoMap, second.ErrorCode)
+	})
+
+	if err := Register(first, first); err == nil {
+		t.Fatal("Register accepted a code twice in one call")
+	}
+
+	if _, ok := Lookup(first.ErrorCode); ok {
+		t.Fatal("a failed Register added codes to the catalog")
+	}
+
+	if err := Register(GLCResponseError{ErrorCode: "TEST_REGISTER_STATUS", HTTPStatusCode: http.StatusOK}); err == nil {
+		t.Error("Register accepted a non error status")
+	}
+
+	if err := Register(first, second); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	if err := Register(second); err == nil {
+		t.Error("Register accepted an already registered code")
+	}
+
+	if err := Register(GLCResponseError{ErrorCode: InternalServerError, HTTPStatusCode: http.StatusInternalServerError}); err == nil {
+		t.Error("Register accepted a built-in code")
+	}
+}
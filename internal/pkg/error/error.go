@@ -1,16 +1,22 @@
 package error
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"reflect"
+	"runtime"
+
+	pkgerrors "github.com/pkg/errors"
 )
 
-const stackSize = 4096
+// stackDepth is the maximum number of frames captured when an error is created.
+const stackDepth = 32
 
 // A Tag represents an error identifier of any type.
 type Tag interface{}
 
-// A Gerror is a tagged error with a stack trace embedded in the Error() string.
+// A Gerror is a tagged error with the stack trace of its creation.
 type Gerror interface {
 	// Returns the tag used to create this error.
 	Tag() Tag
@@ -18,8 +24,8 @@ type Gerror interface {
 	// Returns the concrete type of the tag used to create this error.
 	TagType() reflect.Type
 
-	// Returns the string form of this error,
-	// which includes the tag value, the tag type, the error message, and a stack trace.
+	// Returns the error message, format the error with %+v to include the tag,
+	// the causes and the stack trace.
 	Error() string
 
 	// Test the tag used to create this error for equality with a given tag.
@@ -31,43 +37,65 @@ type Gerror interface {
 
 	// Cause
 	Cause() error
+
+	// Unwrap returns the cause so that errors.Is and errors.As see through the error.
+	Unwrap() error
+
+	// StackTrace returns the stack trace of the creation of the error.
+	StackTrace() pkgerrors.StackTrace
 }
 
 // New Returns an error containing the given tag and message and the current stack trace.
 func New(tag Tag, message string) *GeneralError {
-	return &GeneralError{
-		tag:     tag,
-		typ:     reflect.TypeOf(tag),
-		cause:   nil,
-		message: message,
-	}
+	return newError(tag, nil, message)
 }
 
 // Newf Returns an error containing the given tag and format string and the current stack trace.
 // The given inserts are applied to the format string to produce an error message.
 func Newf(tag Tag, format string, insert ...interface{}) Gerror {
-	return New(tag, fmt.Sprintf(format, insert...))
+	return newError(tag, nil, fmt.Sprintf(format, insert...))
+}
+
+// NewWithFields Returns an error containing the given tag, message and field
+// messages, such as validator.Validator.Errors, and the current stack trace.
+func NewWithFields(tag Tag, message string, fields map[string]string) *GeneralError {
+	err := newError(tag, nil, message)
+	err.fields = fields
+
+	return err
 }
 
 // NewFromError Return an error containing the given tag, the cause of the error, and the current stack trace.
 func NewFromError(tag Tag, cause error) Gerror {
 	if cause != nil {
-		return &GeneralError{
-			tag:     tag,
-			typ:     reflect.TypeOf(tag),
-			cause:   cause,
-			message: cause.Error(),
-		}
+		return newError(tag, cause, cause.Error())
 	}
 
 	return nil
 }
 
+// newError must be called directly by the constructors, the stack starts at their caller.
+func newError(tag Tag, cause error, message string) *GeneralError {
+	var pcs [stackDepth]uintptr
+	// skip runtime.Callers, newError and the constructor
+	n := runtime.Callers(3, pcs[:])
+
+	return &GeneralError{
+		tag:     tag,
+		typ:     reflect.TypeOf(tag),
+		cause:   cause,
+		message: message,
+		stack:   pcs[:n],
+	}
+}
+
 type GeneralError struct {
 	tag     Tag
 	typ     reflect.Type
 	cause   error
 	message string
+	fields  map[string]string
+	stack   []uintptr
 }
 
 func (e *GeneralError) Error() string {
@@ -90,10 +118,68 @@ func (e *GeneralError) Message() string {
 	return e.message
 }
 
+// Fields returns the field messages of the error, nil if it has none.
+func (e *GeneralError) Fields() map[string]string {
+	return e.fields
+}
+
 func (e *GeneralError) Cause() error {
 	return e.cause
 }
 
+func (e *GeneralError) Unwrap() error {
+	return e.cause
+}
+
+// Is matches errors with the same tag, so that errors.Is(err, DBTimeout) or
+// errors.Is(err, New(DBTimeout, "")) holds for any DBTimeout error in the chain.
+func (e *GeneralError) Is(target error) bool {
+	switch t := target.(type) {
+	case ErrorCode:
+		return e.EqualTag(t)
+	case *GeneralError:
+		return e.EqualTag(t.tag)
+	default:
+		return false
+	}
+}
+
+// StackTrace returns the stack trace in the form of github.com/pkg/errors,
+// which the log package adds to error entries.
+func (e *GeneralError) StackTrace() pkgerrors.StackTrace {
+	frames := make(pkgerrors.StackTrace, len(e.stack))
+	for i, pc := range e.stack {
+		frames[i] = pkgerrors.Frame(pc)
+	}
+
+	return frames
+}
+
+// Format prints the message for %s and %v, %+v adds the tag, the causes and
+// the stack trace, %q quotes the message.
+func (e *GeneralError) Format(state fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if state.Flag('+') {
+			fmt.Fprintf(state, "%s: %s", e.tag, e.message)
+
+			if e.cause != nil {
+				fmt.Fprintf(state, "\ncaused by: %+v", e.cause)
+			}
+
+			fmt.Fprintf(state, "%+v", e.StackTrace())
+
+			return
+		}
+
+		io.WriteString(state, e.message)
+	case 's':
+		io.WriteString(state, e.message)
+	case 'q':
+		fmt.Fprintf(state, "%q", e.message)
+	}
+}
+
 // ErrorCode service error constants.
 type ErrorCode string
 
@@ -101,10 +187,15 @@ func (e ErrorCode) String() string {
 	return string(e)
 }
 
+// Error makes an ErrorCode usable as errors.Is target.
+func (e ErrorCode) Error() string {
+	return string(e)
+}
+
 // GetErrorType ...
 func GetErrorType(err error) ErrorCode {
-	gErr, ok := err.(Gerror)
-	if ok {
+	var gErr Gerror
+	if errors.As(err, &gErr) {
 		if errCode, ok2 := gErr.Tag().(ErrorCode); ok2 {
 			return errCode
 		}
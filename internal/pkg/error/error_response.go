@@ -3,20 +3,33 @@ package error
 import (
 	"net/http"
 
+	"catalogue-app/internal/pkg/i18n"
+
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
 )
 
-// GLCResponseError model for returning errors.
+// GLCResponseError model for returning errors, it is the entry of an error code
+// in the catalog, see Register.
 type GLCResponseError struct {
 	HTTPStatusCode     int       `json:"httpStatusCode"`
 	ErrorCode          ErrorCode `json:"errorCode"`
 	Msg                string    `json:"message"`
 	RecommendedActions []string  `json:"recommendedActions"`
+	DocsURL            string    `json:"docsURL,omitempty"`
 }
 
-// errInfoMap represents mapping of errorResponse struct with errorCode.
+// errInfoMap represents mapping of errorResponse struct with errorCode, it
+// holds the built-in codes and those added with Register.
 // nolint:gochecknoglobals, unused
 var errInfoMap = map[ErrorCode]*GLCResponseError{
+	InternalServerError: {
+		HTTPStatusCode:     http.StatusInternalServerError,
+		ErrorCode:          InternalServerError,
+		Msg:                "Internal server error",
+		RecommendedActions: []string{"Contact admin for support"},
+	},
+
 	FailedUnmarshalling: {
 		HTTPStatusCode:     http.StatusBadRequest,
 		ErrorCode:          FailedUnmarshalling,
@@ -53,7 +66,7 @@ var errInfoMap = map[ErrorCode]*GLCResponseError{
 	},
 
 	FailedDataValidation: {
-		HTTPStatusCode:     http.StatusBadRequest,
+		HTTPStatusCode:     http.StatusUnprocessableEntity,
 		ErrorCode:          FailedDataValidation,
 		Msg:                "Failed validating the data content",
 		RecommendedActions: []string{"Reverify the provided data"},
@@ -72,33 +85,85 @@ var errInfoMap = map[ErrorCode]*GLCResponseError{
 		Msg:                "Server cannot process the request",
 		RecommendedActions: []string{"Reverify the provided request"},
 	},
+
+	DBTimeout: {
+		HTTPStatusCode:     http.StatusGatewayTimeout,
+		ErrorCode:          DBTimeout,
+		Msg:                "Database operation timed out",
+		RecommendedActions: []string{"Retry the request after some time"},
+	},
+
+	Unauthorized: {
+		HTTPStatusCode:     http.StatusUnauthorized,
+		ErrorCode:          Unauthorized,
+		Msg:                "Missing or invalid credentials",
+		RecommendedActions: []string{"Pass a valid token while making request"},
+	},
+
+	RateLimited: {
+		HTTPStatusCode:     http.StatusTooManyRequests,
+		ErrorCode:          RateLimited,
+		Msg:                "Too many requests",
+		RecommendedActions: []string{"Reduce the request rate and retry after some time"},
+	},
+
+	NotFound: {
+		HTTPStatusCode:     http.StatusNotFound,
+		ErrorCode:          NotFound,
+		Msg:                "Resource not found",
+		RecommendedActions: []string{"Reverify the requested resource"},
+	},
+
+	Conflict: {
+		HTTPStatusCode:     http.StatusConflict,
+		ErrorCode:          Conflict,
+		Msg:                "Resource already exists",
+		RecommendedActions: []string{"Reverify the provided data"},
+	},
+
+	Retryable: {
+		HTTPStatusCode:     http.StatusServiceUnavailable,
+		ErrorCode:          Retryable,
+		Msg:                "Temporary failure processing the request",
+		RecommendedActions: []string{"Retry the request after the Retry-After delay"},
+	},
 }
 
+// retryAfterSeconds is the Retry-After delay of Retryable errors.
+const retryAfterSeconds = "1"
+
+// RespondWithError writes the error response for err, msg overrides the
+// message of the error code. The response is application/problem+json whenever
+// the Accept header allows it, whatever its position in the header. Clients
+// accepting application/json but not problem+json get the legacy body.
 // nolint:unused
 func RespondWithError(ginCtx *gin.Context, err error, msg string) {
-	status := http.StatusInternalServerError
-	errorCode := InternalServerError
-	message := "Internal server error"
-	recommendActions := []string{"Contact admin for support"}
-
-	errType := GetErrorType(err)
-
-	reflectErr, ok := errInfoMap[errType]
-	if ok {
-		status = reflectErr.HTTPStatusCode
-		errorCode = reflectErr.ErrorCode
-		message = reflectErr.Msg
-		recommendActions = reflectErr.RecommendedActions
+	problem := NewProblem(ginCtx.Request.Context(), err, msg)
+	ginCtx.Header("Content-Language", i18n.Language(ginCtx.Request.Context()).String())
+
+	if problem.ErrorCode == Retryable {
+		ginCtx.Header("Retry-After", retryAfterSeconds)
 	}
 
-	if msg != "" {
-		message = msg
+	accept := ginCtx.GetHeader("Accept")
+	if !acceptsMediaType(accept, ProblemContentType) && acceptsMediaType(accept, legacyContentType) {
+		body := gin.H{
+			"HTTPStatusCode":     problem.Status,
+			"errorCode":          problem.ErrorCode,
+			"message":            problem.message(),
+			"recommendedActions": problem.RecommendedActions,
+		}
+
+		if len(problem.Errors) > 0 {
+			body["errors"] = problem.Errors
+		}
+
+		ginCtx.JSON(problem.Status, body)
+
+		return
 	}
 
-	ginCtx.JSON(status, gin.H{
-		"HTTPStatusCode":     status,
-		"errorCode":          errorCode,
-		"message":            message,
-		"recommendedActions": recommendActions,
-	})
+	// set before rendering, render.JSON keeps an existing content type
+	ginCtx.Header("Content-Type", ProblemContentType)
+	ginCtx.Render(problem.Status, render.JSON{Data: problem})
 }
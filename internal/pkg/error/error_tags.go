@@ -28,6 +28,25 @@ const (
 	// BadRequest provides error code for cases where server cannot process the request.
 	BadRequest ErrorCode = "BAD_REQUEST"
 
+	// DBTimeout provides error code for database operations exceeding their deadline.
+	DBTimeout ErrorCode = "DB_TIMEOUT"
+
+	// Unauthorized provides error code for requests missing valid credentials.
+	Unauthorized ErrorCode = "UNAUTHORIZED"
+
+	// RateLimited provides error code for clients exceeding the request rate limit.
+	RateLimited ErrorCode = "RATE_LIMITED"
+
+	// NotFound provides error code for resources that don't exist.
+	NotFound ErrorCode = "NOT_FOUND"
+
+	// Conflict provides error code for requests conflicting with existing resources.
+	Conflict ErrorCode = "CONFLICT"
+
+	// Retryable provides error code for transient failures, such as database
+	// deadlocks, which succeed when retried.
+	Retryable ErrorCode = "RETRYABLE"
+
 	// InternalServerError provides error code for some internal error.
-	InternalServerError ErrorCode = "HPE_GL_MP_INTERNAL_ERROR"
+	InternalServerError ErrorCode = "INTERNAL_SERVER_ERROR"
 )
\ No newline at end of file
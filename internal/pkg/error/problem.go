@@ -0,0 +1,140 @@
+package error
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"catalogue-app/internal/pkg/i18n"
+	"catalogue-app/internal/pkg/requestid"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+const legacyContentType = "application/json"
+
+// acceptsMediaType reports whether an Accept header allows mediaType. As in
+// RFC 7231 the most specific matching range decides, the exact type before
+// type/* before */*, and a quality of 0 refuses it. An empty header accepts
+// everything.
+func acceptsMediaType(accept string, mediaType string) bool {
+	if strings.TrimSpace(accept) == "" {
+		return true
+	}
+
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	specificity, q := -1, 0.0
+
+	for _, mediaRange := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(mediaRange, ";")
+
+		var rangeSpecificity int
+
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case mediaType:
+			rangeSpecificity = 2
+		case mainType + "/*":
+			rangeSpecificity = 1
+		case "*/*":
+			rangeSpecificity = 0
+		default:
+			continue
+		}
+
+		if rangeSpecificity > specificity {
+			specificity, q = rangeSpecificity, quality(params)
+		}
+	}
+
+	return q > 0
+}
+
+// quality returns the q parameter of a media range, 1 if it has none.
+func quality(params string) float64 {
+	for _, param := range strings.Split(params, ";") {
+		key, value, _ := strings.Cut(param, "=")
+		if strings.TrimSpace(key) != "q" {
+			continue
+		}
+
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return 0
+		}
+
+		return q
+	}
+
+	return 1
+}
+
+// ProblemTypeBase prefixes the error code to form the problem type URI of codes
+// registered without a docs URL.
+var ProblemTypeBase = "/v1/errors/"
+
+// Problem is an RFC 7807 problem details body, extended with the error code,
+// the recommended actions and the field errors of a failed validation.
+type Problem struct {
+	Type               string            `json:"type"`
+	Title              string            `json:"title"`
+	Status             int               `json:"status"`
+	Detail             string            `json:"detail,omitempty"`
+	Instance           string            `json:"instance,omitempty"`
+	ErrorCode          ErrorCode         `json:"errorCode"`
+	RecommendedActions []string          `json:"recommendedActions,omitempty"`
+	Errors             map[string]string `json:"errors,omitempty"`
+}
+
+// fieldErrorer is implemented by errors carrying field level messages.
+type fieldErrorer interface {
+	Fields() map[string]string
+}
+
+// NewProblem returns the problem details of err, msg becomes the detail.
+// Unknown error codes are reported as internal server errors. The instance is
+// the request ID of the context.
+func NewProblem(ctx context.Context, err error, msg string) Problem {
+	info, ok := Lookup(GetErrorType(err))
+	if !ok {
+		info, _ = Lookup(InternalServerError)
+	}
+
+	problem := Problem{
+		Type:               info.DocsURL,
+		Title:              info.Msg,
+		Status:             info.HTTPStatusCode,
+		Detail:             msg,
+		Instance:           requestid.FromContext(ctx),
+		ErrorCode:          info.ErrorCode,
+		RecommendedActions: info.RecommendedActions,
+	}
+
+	if message, actions, ok := i18n.ErrorMessage(i18n.Language(ctx), string(info.ErrorCode)); ok {
+		problem.Title = message
+		if len(actions) > 0 {
+			problem.RecommendedActions = actions
+		}
+	}
+
+	if problem.Type == "" {
+		problem.Type = ProblemTypeBase + string(problem.ErrorCode)
+	}
+
+	var fieldErr fieldErrorer
+	if errors.As(err, &fieldErr) {
+		problem.Errors = fieldErr.Fields()
+	}
+
+	return problem
+}
+
+// message is the message of the legacy body, the detail if set and the title otherwise.
+func (problem Problem) message() string {
+	if problem.Detail != "" {
+		return problem.Detail
+	}
+
+	return problem.Title
+}
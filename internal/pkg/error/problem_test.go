@@ -0,0 +1,37 @@
+package error
+
+import "testing"
+
+func TestAcceptsMediaType(t *testing.T) {
+	tests := []struct {
+		accept  string
+		problem bool
+		legacy  bool
+	}{
+		{accept: "", problem: true, legacy: true},
+		{accept: "*/*", problem: true, legacy: true},
+		{accept: "application/*", problem: true, legacy: true},
+		{accept: "application/problem+json", problem: true, legacy: false},
+		{accept: "application/json", problem: false, legacy: true},
+		{accept: "application/json, application/problem+json", problem: true, legacy: true},
+		{accept: "application/json;q=0.9, application/problem+json;q=0.1", problem: true, legacy: true},
+		{accept: "Application/Problem+JSON", problem: true, legacy: false},
+		{accept: "application/json, application/problem+json;q=0", problem: false, legacy: true},
+		{accept: "application/json, */*;q=0", problem: false, legacy: true},
+		{accept: "*/*, application/problem+json;q=0", problem: false, legacy: true},
+		{accept: "application/problem+json;q=0, */*", problem: false, legacy: true},
+		{accept: "application/*;q=0, application/json", problem: false, legacy: true},
+		{accept: "application/problem+json;q=0.5, */*;q=0", problem: true, legacy: false},
+		{accept: "text/html", problem: false, legacy: false},
+	}
+
+	for _, test := range tests {
+		if got := acceptsMediaType(test.accept, ProblemContentType); got != test.problem {
+			t.Errorf("acceptsMediaType(%q, problem+json) = %v, want %v", test.accept, got, test.problem)
+		}
+
+		if got := acceptsMediaType(test.accept, legacyContentType); got != test.legacy {
+			t.Errorf("acceptsMediaType(%q, json) = %v, want %v", test.accept, got, test.legacy)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package features
+
+import (
+	"sync/atomic"
+)
+
+// enabled holds the set of enabled feature flags, replaced as a whole on reload.
+var enabled atomic.Value
+
+func init() {
+	enabled.Store(map[string]bool{})
+}
+
+// Set replaces the enabled feature flags.
+func Set(names []string) {
+	flags := make(map[string]bool, len(names))
+	for _, name := range names {
+		flags[name] = true
+	}
+
+	enabled.Store(flags)
+}
+
+// Enabled returns true if the feature flag is enabled.
+func Enabled(name string) bool {
+	return enabled.Load().(map[string]bool)[name]
+}
+
+// List returns the enabled feature flags.
+func List() []string {
+	flags := enabled.Load().(map[string]bool)
+
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+
+	return names
+}
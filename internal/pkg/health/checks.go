@@ -0,0 +1,71 @@
+package health
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"time"
+)
+
+// CertExpiry returns a check failing when any certificate in the given PEM
+// files expires within threshold. Empty paths are skipped.
+func CertExpiry(threshold time.Duration, paths ...string) CheckFunc {
+	return func(ctx context.Context) error {
+		for _, path := range paths {
+			if path == "" {
+				continue
+			}
+
+			notAfter, err := earliestExpiry(path)
+			if err != nil {
+				return err
+			}
+
+			if remaining := time.Until(notAfter); remaining < threshold {
+				return fmt.Errorf("certificate %s expires at %s", path, notAfter.Format(time.RFC3339))
+			}
+		}
+
+		return nil
+	}
+}
+
+// earliestExpiry returns the earliest NotAfter of all certificates in a PEM file.
+func earliestExpiry(path string) (time.Time, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var earliest time.Time
+
+	for {
+		var block *pem.Block
+
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if earliest.IsZero() || cert.NotAfter.Before(earliest) {
+			earliest = cert.NotAfter
+		}
+	}
+
+	if earliest.IsZero() {
+		return time.Time{}, fmt.Errorf("no certificate found in %s", path)
+	}
+
+	return earliest, nil
+}
@@ -0,0 +1,179 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// StatusOK is reported for passing checks and healthy reports.
+	StatusOK = "ok"
+
+	// StatusFail is reported for failing checks and unhealthy reports.
+	StatusFail = "fail"
+)
+
+// CheckFunc checks a single dependency, a nil error means healthy.
+type CheckFunc func(ctx context.Context) error
+
+// Check is a named dependency check.
+type Check struct {
+	Name string
+	Fn   CheckFunc
+
+	// Critical checks flip the report to StatusFail when failing, the result
+	// of non-critical checks is reported but doesn't affect readiness.
+	Critical bool
+}
+
+// Result is the outcome of a single check.
+type Result struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Critical bool   `json:"critical"`
+	Latency  string `json:"latency"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Report is the aggregated outcome of all registered checks.
+type Report struct {
+	Status    string    `json:"status"`
+	CheckedAt time.Time `json:"checkedAt"`
+	Checks    []Result  `json:"checks"`
+}
+
+// Healthy returns true if no critical check failed.
+func (report Report) Healthy() bool {
+	return report.Status == StatusOK
+}
+
+// Registry runs the registered checks and caches the report for a short
+// period so that frequent probes don't stampede the dependencies.
+type Registry struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	mutex   sync.Mutex
+	checks  []Check
+	cached  *Report
+	running *run
+}
+
+// run is an in-flight run of the checks, report is set once done is closed.
+type run struct {
+	done   chan struct{}
+	report Report
+}
+
+// NewRegistry returns a Registry bounding every check by timeout and caching
+// reports for cacheTTL.
+func NewRegistry(timeout time.Duration, cacheTTL time.Duration) *Registry {
+	return &Registry{timeout: timeout, cacheTTL: cacheTTL}
+}
+
+// Register adds a check to the registry.
+func (registry *Registry) Register(check Check) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	registry.checks = append(registry.checks, check)
+	registry.cached = nil
+	// a run in flight misses the check, its report is not cached
+	registry.running = nil
+}
+
+// Run returns the cached report if still fresh, otherwise it runs all checks
+// concurrently. Callers arriving while checks run share the result. The checks
+// are detached from ctx, so that a cancelled probe doesn't fail the others, and
+// a caller whose ctx ends first gets a failed report that is not cached.
+func (registry *Registry) Run(ctx context.Context) Report {
+	registry.mutex.Lock()
+
+	if registry.cached != nil && time.Since(registry.cached.CheckedAt) < registry.cacheTTL {
+		report := *registry.cached
+		registry.mutex.Unlock()
+
+		return report
+	}
+
+	current := registry.running
+	if current == nil {
+		current = &run{done: make(chan struct{})}
+		registry.running = current
+
+		go registry.runChecks(current, registry.checks)
+	}
+
+	registry.mutex.Unlock()
+
+	select {
+	case <-current.done:
+		return current.report
+	case <-ctx.Done():
+		return Report{Status: StatusFail, CheckedAt: time.Now(), Checks: []Result{}}
+	}
+}
+
+// runChecks runs the checks of current and caches the report, unless the
+// checks changed while they ran.
+func (registry *Registry) runChecks(current *run, checks []Check) {
+	report := Report{
+		Status:    StatusOK,
+		CheckedAt: time.Now(),
+		Checks:    make([]Result, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+
+		go func(i int, check Check) {
+			defer wg.Done()
+			report.Checks[i] = registry.runCheck(context.Background(), check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Critical && result.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	current.report = report
+	close(current.done)
+
+	if registry.running == current {
+		registry.running = nil
+		registry.cached = &report
+	}
+}
+
+func (registry *Registry) runCheck(ctx context.Context, check Check) Result {
+	if registry.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, registry.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := check.Fn(ctx)
+
+	result := Result{
+		Name:     check.Name,
+		Status:   StatusOK,
+		Critical: check.Critical,
+		Latency:  time.Since(start).String(),
+	}
+
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	return result
+}
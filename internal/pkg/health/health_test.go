@@ -0,0 +1,179 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func passing(context.Context) error {
+	return nil
+}
+
+func failing(context.Context) error {
+	return errors.New("unreachable")
+}
+
+func TestRunStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks []Check
+		status string
+	}{
+		{name: "no checks", status: StatusOK},
+		{
+			name:   "passing",
+			checks: []Check{{Name: "database", Fn: passing, Critical: true}, {Name: "smtp", Fn: passing}},
+			status: StatusOK,
+		},
+		{
+			name:   "failing non-critical",
+			checks: []Check{{Name: "database", Fn: passing, Critical: true}, {Name: "smtp", Fn: failing}},
+			status: StatusOK,
+		},
+		{
+			name:   "failing critical",
+			checks: []Check{{Name: "database", Fn: failing, Critical: true}, {Name: "smtp", Fn: passing}},
+			status: StatusFail,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			registry := NewRegistry(time.Second, 0)
+			for _, check := range test.checks {
+				registry.Register(check)
+			}
+
+			report := registry.Run(context.Background())
+			if report.Status != test.status {
+				t.Errorf("got status %s, want %s", report.Status, test.status)
+			}
+
+			if len(report.Checks) != len(test.checks) {
+				t.Fatalf("got %d results, want %d", len(report.Checks), len(test.checks))
+			}
+
+			for i, result := range report.Checks {
+				check := test.checks[i]
+
+				wantStatus := StatusOK
+				if check.Fn(context.Background()) != nil {
+					wantStatus = StatusFail
+				}
+
+				if result.Name != check.Name || result.Critical != check.Critical || result.Status != wantStatus {
+					t.Errorf("got result %+v for check %s", result, check.Name)
+				}
+
+				if (result.Error != "") != (wantStatus == StatusFail) {
+					t.Errorf("got error %q for check %s", result.Error, check.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestRunCache(t *testing.T) {
+	var calls atomic.Int32
+
+	registry := NewRegistry(time.Second, time.Hour)
+	registry.Register(Check{Name: "counted", Fn: func(context.Context) error {
+		calls.Add(1)
+
+		return nil
+	}})
+
+	registry.Run(context.Background())
+	registry.Run(context.Background())
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("checks ran %d times within the cache TTL, want 1", got)
+	}
+
+	registry.Register(Check{Name: "added", Fn: passing})
+
+	if report := registry.Run(context.Background()); len(report.Checks) != 2 || calls.Load() != 2 {
+		t.Errorf("Register didn't invalidate the cache, got %d results after %d runs", len(report.Checks), calls.Load())
+	}
+
+	uncached := NewRegistry(time.Second, 0)
+	uncached.Register(Check{Name: "counted", Fn: func(context.Context) error {
+		calls.Add(1)
+
+		return nil
+	}})
+
+	uncached.Run(context.Background())
+	uncached.Run(context.Background())
+
+	if got := calls.Load(); got != 4 {
+		t.Errorf("checks ran %d times without a cache, want 4", got)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	registry := NewRegistry(10*time.Millisecond, time.Hour)
+	registry.Register(Check{Name: "slow", Critical: true, Fn: func(ctx context.Context) error {
+		<-ctx.Done()
+
+		return ctx.Err()
+	}})
+
+	report := registry.Run(context.Background())
+	if report.Status != StatusFail || report.Checks[0].Error != context.DeadlineExceeded.Error() {
+		t.Errorf("got report %+v, want the check to time out", report)
+	}
+}
+
+// TestRunCancelledCaller checks that a probe giving up neither fails the checks
+// shared with other probes nor leaves a failed report in the cache.
+func TestRunCancelledCaller(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	registry := NewRegistry(time.Second, time.Hour)
+	registry.Register(Check{Name: "database", Critical: true, Fn: func(ctx context.Context) error {
+		close(started)
+
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan Report)
+
+	go func() {
+		first <- registry.Run(ctx)
+	}()
+
+	<-started
+
+	waiting := make(chan Report)
+
+	go func() {
+		waiting <- registry.Run(context.Background())
+	}()
+
+	cancel()
+
+	if report := <-first; report.Status != StatusFail {
+		t.Errorf("cancelled caller got %+v, want a failed report", report)
+	}
+
+	close(release)
+
+	if report := <-waiting; report.Status != StatusOK {
+		t.Errorf("waiting caller got %+v, want the shared checks to pass", report)
+	}
+
+	if report := registry.Run(context.Background()); report.Status != StatusOK {
+		t.Errorf("cached report is %+v, want the passing report", report)
+	}
+}
@@ -0,0 +1,153 @@
+package i18n
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"path"
+	"strings"
+
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+)
+
+// English is the fallback language, its catalog must define every validation rule.
+var English = language.English
+
+// Catalogs are embedded from locales/<language>.yaml, adding a file adds a language.
+//
+//go:embed locales/*.yaml
+var localeFS embed.FS
+
+// catalog holds the messages of a language. Error messages are keyed by error
+// code and fall back to the error catalog of the error package, validation
+// messages are keyed by rule and take {name} parameters.
+type catalog struct {
+	Errors     map[string]errorMessage `yaml:"errors"`
+	Validation map[string]string       `yaml:"validation"`
+}
+
+type errorMessage struct {
+	Message            string   `yaml:"message"`
+	RecommendedActions []string `yaml:"recommendedActions"`
+}
+
+type languageContextKey struct{}
+
+var (
+	catalogs  = make(map[language.Tag]catalog)
+	supported []language.Tag
+	matcher   language.Matcher
+)
+
+func init() {
+	if err := load(); err != nil {
+		panic(err)
+	}
+}
+
+func load() error {
+	entries, err := localeFS.ReadDir("locales")
+	if err != nil {
+		return err
+	}
+
+	// English first, the matcher falls back to the first tag
+	supported = []language.Tag{English}
+
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), path.Ext(entry.Name()))
+
+		tag, err := language.Parse(name)
+		if err != nil {
+			return fmt.Errorf("locale %s has no valid language name %v", entry.Name(), err)
+		}
+
+		data, err := localeFS.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			return err
+		}
+
+		var messages catalog
+		if err := yaml.Unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("locale %s failed %v", entry.Name(), err)
+		}
+
+		catalogs[tag] = messages
+
+		if tag != English {
+			supported = append(supported, tag)
+		}
+	}
+
+	if _, ok := catalogs[English]; !ok {
+		return fmt.Errorf("locale %s.yaml is missing", English)
+	}
+
+	matcher = language.NewMatcher(supported)
+
+	return nil
+}
+
+// Match returns the supported language best matching an Accept-Language header, English if none does.
+func Match(acceptLanguage string) language.Tag {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return English
+	}
+
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return English
+	}
+
+	return supported[index]
+}
+
+// Supported returns the languages with a catalog, English first.
+func Supported() []language.Tag {
+	return append([]language.Tag(nil), supported...)
+}
+
+// WithLanguage returns a context carrying the language of the request.
+func WithLanguage(ctx context.Context, tag language.Tag) context.Context {
+	return context.WithValue(ctx, languageContextKey{}, tag)
+}
+
+// Language returns the language of the context, English if none is set.
+func Language(ctx context.Context) language.Tag {
+	tag, ok := ctx.Value(languageContextKey{}).(language.Tag)
+	if !ok {
+		return English
+	}
+
+	return tag
+}
+
+// ErrorMessage returns the translated message and recommended actions of an
+// error code, false if the language has no translation for it.
+func ErrorMessage(tag language.Tag, code string) (string, []string, bool) {
+	message, ok := catalogs[tag].Errors[code]
+	if !ok || message.Message == "" {
+		return "", nil, false
+	}
+
+	return message.Message, message.RecommendedActions, true
+}
+
+// Validation returns the message of a validation rule in the language, or in
+// English if the language has none, with its {name} parameters replaced.
+func Validation(tag language.Tag, rule string, params map[string]interface{}) (string, bool) {
+	message, ok := catalogs[tag].Validation[rule]
+	if !ok {
+		if message, ok = catalogs[English].Validation[rule]; !ok {
+			return "", false
+		}
+	}
+
+	for name, value := range params {
+		message = strings.ReplaceAll(message, "{"+name+"}", fmt.Sprint(value))
+	}
+
+	return message, true
+}
@@ -0,0 +1,92 @@
+package i18n
+
+import (
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestMessages(t *testing.T) {
+	tests := []struct {
+		acceptLanguage string
+		language       language.Tag
+		errorMessage   string
+		validation     string
+	}{
+		{acceptLanguage: "de", language: language.German, errorMessage: "Ressource nicht gefunden", validation: "darf nicht länger als 10 Bytes sein"},
+		{acceptLanguage: "de-AT", language: language.German, errorMessage: "Ressource nicht gefunden", validation: "darf nicht länger als 10 Bytes sein"},
+		{acceptLanguage: "fr-CH, fr;q=0.9, de;q=0.5", language: language.German, errorMessage: "Ressource nicht gefunden", validation: "darf nicht länger als 10 Bytes sein"},
+		{acceptLanguage: "fr", language: English, validation: "must not be more than 10 bytes long"},
+		{acceptLanguage: "", language: English, validation: "must not be more than 10 bytes long"},
+		{acceptLanguage: "not a header;;", language: English, validation: "must not be more than 10 bytes long"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.acceptLanguage, func(t *testing.T) {
+			tag := Match(test.acceptLanguage)
+			if tag != test.language {
+				t.Fatalf("got language %s, want %s", tag, test.language)
+			}
+
+			// English error messages come from the error catalog instead
+			message, actions, ok := ErrorMessage(tag, "NOT_FOUND")
+			if ok != (test.errorMessage != "") || message != test.errorMessage {
+				t.Errorf("got error message %q found %v, want %q", message, ok, test.errorMessage)
+			}
+
+			if ok && len(actions) == 0 {
+				t.Error("got no recommended actions")
+			}
+
+			validation, ok := Validation(tag, "max_bytes", map[string]interface{}{"max": 10})
+			if !ok || validation != test.validation {
+				t.Errorf("got validation message %q found %v, want %q", validation, ok, test.validation)
+			}
+		})
+	}
+}
+
+func TestMissingTranslation(t *testing.T) {
+	german := catalogs[language.German]
+	t.Cleanup(func() {
+		catalogs[language.German] = german
+	})
+
+	// a catalog lacking the CONFLICT error and the unique rule
+	partial := catalog{Errors: make(map[string]errorMessage), Validation: make(map[string]string)}
+	for code, message := range german.Errors {
+		if code != "CONFLICT" {
+			partial.Errors[code] = message
+		}
+	}
+
+	for rule, message := range german.Validation {
+		if rule != "unique" {
+			partial.Validation[rule] = message
+		}
+	}
+
+	catalogs[language.German] = partial
+
+	if message, _, ok := ErrorMessage(language.German, "CONFLICT"); ok {
+		t.Errorf("got %q for an untranslated error code", message)
+	}
+
+	if message, ok := Validation(language.German, "unique", nil); !ok || message != "must not contain duplicate values" {
+		t.Errorf("got %q found %v, want the English message", message, ok)
+	}
+
+	if message, ok := Validation(language.German, "no_such_rule", nil); ok {
+		t.Errorf("got %q for an unknown rule", message)
+	}
+}
+
+func TestCatalogsComplete(t *testing.T) {
+	for tag, messages := range catalogs {
+		for rule := range messages.Validation {
+			if _, ok := catalogs[English].Validation[rule]; !ok {
+				t.Errorf("rule %s of %s is missing from en.yaml", rule, tag)
+			}
+		}
+	}
+}
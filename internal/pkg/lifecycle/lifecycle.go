@@ -0,0 +1,90 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"catalogue-app/internal/pkg/log"
+)
+
+// Hook is a pair of start and stop callbacks of a component, either may be nil.
+type Hook struct {
+	Name    string
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+// Manager starts components in registration order and stops them in reverse
+// order, so a component registered first is started first and stopped last.
+type Manager struct {
+	mutex   sync.Mutex
+	hooks   []Hook
+	started int
+}
+
+// New returns an empty Manager.
+func New() *Manager {
+	return &Manager{}
+}
+
+// Append registers a hook after the already registered ones.
+func (manager *Manager) Append(hook Hook) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	manager.hooks = append(manager.hooks, hook)
+}
+
+// Start runs the start hooks in order. If one fails, the hooks started so far
+// are stopped and the start error is returned, stop errors are only logged.
+func (manager *Manager) Start(ctx context.Context) error {
+	manager.mutex.Lock()
+	hooks := manager.hooks
+	manager.mutex.Unlock()
+
+	for _, hook := range hooks {
+		if hook.OnStart != nil {
+			if err := hook.OnStart(ctx); err != nil {
+				_ = manager.Stop(ctx)
+
+				return fmt.Errorf("starting %s: %w", hook.Name, err)
+			}
+		}
+
+		manager.mutex.Lock()
+		manager.started++
+		manager.mutex.Unlock()
+	}
+
+	return nil
+}
+
+// Stop runs the stop hooks of all started components in reverse order. Every
+// hook runs even if a previous one failed, the first error is returned.
+func (manager *Manager) Stop(ctx context.Context) error {
+	manager.mutex.Lock()
+	hooks := manager.hooks[:manager.started]
+	manager.started = 0
+	manager.mutex.Unlock()
+
+	var firstErr error
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+		if hook.OnStop == nil {
+			continue
+		}
+
+		log.Infof(ctx, "stopping %s ...", hook.Name)
+
+		if err := hook.OnStop(ctx); err != nil {
+			log.Errorf(ctx, "failed stopping %s: %v", hook.Name, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("stopping %s: %w", hook.Name, err)
+			}
+		}
+	}
+
+	return firstErr
+}
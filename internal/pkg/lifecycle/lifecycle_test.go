@@ -0,0 +1,133 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// recorder appends the start and stop calls of hooks.
+type recorder struct {
+	calls []string
+}
+
+func (rec *recorder) hook(name string, startErr error) Hook {
+	return Hook{
+		Name: name,
+		OnStart: func(context.Context) error {
+			rec.calls = append(rec.calls, "start "+name)
+
+			return startErr
+		},
+		OnStop: func(context.Context) error {
+			rec.calls = append(rec.calls, "stop "+name)
+
+			return nil
+		},
+	}
+}
+
+func TestStopReverseOrder(t *testing.T) {
+	rec := &recorder{}
+	manager := New()
+
+	manager.Append(rec.hook("database", nil))
+	manager.Append(Hook{Name: "no callbacks"})
+	manager.Append(rec.hook("workers", nil))
+	manager.Append(rec.hook("http server", nil))
+
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if err := manager.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	want := []string{
+		"start database", "start workers", "start http server",
+		"stop http server", "stop workers", "stop database",
+	}
+	if !reflect.DeepEqual(rec.calls, want) {
+		t.Errorf("got calls %v, want %v", rec.calls, want)
+	}
+
+	// stopped hooks are not stopped again
+	if err := manager.Stop(context.Background()); err != nil || len(rec.calls) != len(want) {
+		t.Errorf("second Stop ran hooks again: %v", rec.calls)
+	}
+}
+
+func TestStartRollback(t *testing.T) {
+	startErr := errors.New("port in use")
+
+	rec := &recorder{}
+	manager := New()
+
+	manager.Append(rec.hook("database", nil))
+	manager.Append(rec.hook("workers", nil))
+	manager.Append(rec.hook("http server", startErr))
+	manager.Append(rec.hook("readiness", nil))
+
+	err := manager.Start(context.Background())
+	if !errors.Is(err, startErr) {
+		t.Fatalf("got error %v, want %v", err, startErr)
+	}
+
+	// the failed hook is not stopped, the later hook is never started
+	want := []string{
+		"start database", "start workers", "start http server",
+		"stop workers", "stop database",
+	}
+	if !reflect.DeepEqual(rec.calls, want) {
+		t.Errorf("got calls %v, want %v", rec.calls, want)
+	}
+}
+
+func TestStopDeadline(t *testing.T) {
+	rec := &recorder{}
+	manager := New()
+
+	manager.Append(Hook{
+		Name: "logger",
+		OnStop: func(context.Context) error {
+			rec.calls = append(rec.calls, "stop logger")
+
+			return errors.New("sync failed")
+		},
+	})
+	manager.Append(rec.hook("database", nil))
+	manager.Append(Hook{
+		Name: "http server",
+		OnStop: func(ctx context.Context) error {
+			<-ctx.Done()
+
+			return ctx.Err()
+		},
+	})
+
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	begin := time.Now()
+	err := manager.Stop(ctx)
+
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("Stop took %s with a 20ms deadline", elapsed)
+	}
+
+	// the first error is returned and the remaining hooks still stop
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	if want := []string{"start database", "stop database", "stop logger"}; !reflect.DeepEqual(rec.calls, want) {
+		t.Errorf("got calls %v, want %v", rec.calls, want)
+	}
+}
@@ -0,0 +1,108 @@
+package log
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Fields are structured fields attached to log entries.
+type Fields map[string]interface{}
+
+// Field is a typed structured field of the Infow style API, the constructors
+// below avoid the reflection of Any.
+type Field = zapcore.Field
+
+// String returns a string field.
+func String(key string, value string) Field {
+	return zap.String(key, value)
+}
+
+// Int returns an int field.
+func Int(key string, value int) Field {
+	return zap.Int(key, value)
+}
+
+// Bool returns a bool field.
+func Bool(key string, value bool) Field {
+	return zap.Bool(key, value)
+}
+
+// Duration returns a duration field.
+func Duration(key string, value time.Duration) Field {
+	return zap.Duration(key, value)
+}
+
+// Err returns an error field under the key error.
+func Err(err error) Field {
+	return zap.Error(err)
+}
+
+// Any returns a field for any value, using reflection for unknown types.
+func Any(key string, value interface{}) Field {
+	return zap.Any(key, value)
+}
+
+type (
+	userIDContextKey   struct{}
+	tenantIDContextKey struct{}
+	fieldsContextKey   struct{}
+)
+
+// WithUserID returns a context carrying the user ID, it is logged with every
+// entry of the context and matched by user scoped levels.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDContextKey{}, userID)
+}
+
+// UserID returns the user ID stored in the context.
+func UserID(ctx context.Context) string {
+	uID, ok := ctx.Value(userIDContextKey{}).(string)
+	if !ok {
+		uID = ""
+	}
+
+	return uID
+}
+
+// WithTenantID returns a context carrying the tenant ID, it is logged with every
+// entry of the context.
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantIDContextKey{}, tenantID)
+}
+
+// TenantID returns the tenant ID stored in the context.
+func TenantID(ctx context.Context) string {
+	tID, ok := ctx.Value(tenantIDContextKey{}).(string)
+	if !ok {
+		tID = ""
+	}
+
+	return tID
+}
+
+// WithFields returns a context carrying fields which are added to every later
+// entry logged with it, on top of the fields already in the context.
+func WithFields(ctx context.Context, fields Fields) context.Context {
+	merged := make(Fields, len(fields))
+	for key, value := range fieldsFromContext(ctx) {
+		merged[key] = value
+	}
+
+	for key, value := range fields {
+		merged[key] = value
+	}
+
+	return context.WithValue(ctx, fieldsContextKey{}, merged)
+}
+
+func fieldsFromContext(ctx context.Context) Fields {
+	fields, ok := ctx.Value(fieldsContextKey{}).(Fields)
+	if !ok {
+		return nil
+	}
+
+	return fields
+}
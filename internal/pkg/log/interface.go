@@ -20,4 +20,14 @@ type Logger interface {
 
 	Audit(ctx context.Context, args ...interface{})
 	Auditf(ctx context.Context, format string, args ...interface{})
-}
\ No newline at end of file
+
+	// The w variants log a constant message with structured fields.
+	Infow(ctx context.Context, msg string, fields ...Field)
+	Warnw(ctx context.Context, msg string, fields ...Field)
+	Errorw(ctx context.Context, msg string, fields ...Field)
+	Debugw(ctx context.Context, msg string, fields ...Field)
+	Auditw(ctx context.Context, msg string, fields ...Field)
+
+	// Sync flushes any buffered log entries.
+	Sync() error
+}
@@ -0,0 +1,125 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Scope selects the entries a scoped level applies to, by request or by user.
+// Exactly one of the fields must be set.
+type Scope struct {
+	RequestID string `json:"requestID,omitempty"`
+	UserID    string `json:"userID,omitempty"`
+}
+
+// ScopedLevel lowers the level for the entries matching its scope until it expires.
+type ScopedLevel struct {
+	Scope
+	Level     string    `json:"level"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+var (
+	scopedMutex  sync.RWMutex
+	scopedLevels = make(map[Scope]ScopedLevel)
+
+	// scopedCount lets logging skip the scope lookup when none is set.
+	scopedCount atomic.Int32
+)
+
+// ParseLevel parses any zap level name: debug, info, warn, error, dpanic, panic, fatal.
+func ParseLevel(level string) (zapcore.Level, error) {
+	return zapcore.ParseLevel(level)
+}
+
+// SetScopedLevel sets the level for entries of a single request or user for
+// ttl, so that one customer can be debugged without flooding the logs.
+func SetScopedLevel(scope Scope, level string, ttl time.Duration) error {
+	if (scope.RequestID == "") == (scope.UserID == "") {
+		return errors.New("scope needs either a request ID or a user ID")
+	}
+
+	if ttl <= 0 {
+		return errors.New("scoped level needs a positive ttl")
+	}
+
+	if _, err := ParseLevel(level); err != nil {
+		return err
+	}
+
+	scopedMutex.Lock()
+	defer scopedMutex.Unlock()
+
+	scopedLevels[scope] = ScopedLevel{Scope: scope, Level: level, ExpiresAt: time.Now().Add(ttl)}
+	scopedCount.Store(int32(len(scopedLevels)))
+
+	return nil
+}
+
+// RemoveScopedLevel removes the level set for scope.
+func RemoveScopedLevel(scope Scope) {
+	scopedMutex.Lock()
+	defer scopedMutex.Unlock()
+
+	delete(scopedLevels, scope)
+	scopedCount.Store(int32(len(scopedLevels)))
+}
+
+// ScopedLevels returns the scoped levels which haven't expired yet.
+func ScopedLevels() []ScopedLevel {
+	scopedMutex.Lock()
+	defer scopedMutex.Unlock()
+
+	now := time.Now()
+	levels := make([]ScopedLevel, 0, len(scopedLevels))
+
+	for scope, scoped := range scopedLevels {
+		if now.After(scoped.ExpiresAt) {
+			delete(scopedLevels, scope)
+
+			continue
+		}
+
+		levels = append(levels, scoped)
+	}
+
+	scopedCount.Store(int32(len(scopedLevels)))
+
+	return levels
+}
+
+// scopedLevelFor returns the level of an unexpired scope matching the request
+// or user of the context.
+func scopedLevelFor(ctx context.Context) (zapcore.Level, bool) {
+	if scopedCount.Load() == 0 {
+		return 0, false
+	}
+
+	scopedMutex.RLock()
+	defer scopedMutex.RUnlock()
+
+	for _, scope := range []Scope{
+		{RequestID: RequestID(ctx)},
+		{UserID: UserID(ctx)},
+	} {
+		if scope == (Scope{}) {
+			continue
+		}
+
+		scoped, ok := scopedLevels[scope]
+		if !ok || time.Now().After(scoped.ExpiresAt) {
+			continue
+		}
+
+		if lvl, err := ParseLevel(scoped.Level); err == nil {
+			return lvl, true
+		}
+	}
+
+	return 0, false
+}
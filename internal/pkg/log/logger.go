@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"strings"
 
+	"catalogue-app/internal/pkg/requestid"
+
 	"github.com/pkg/errors"
 )
 
@@ -16,10 +18,9 @@ const (
 	reqID      = "requestID"
 	tenantID   = "tenantID"
 	userID     = "userID"
-	time       = "@timestamp"
+	timeKey    = "@timestamp"
 	stackTrace = "stacktrace"
 	typeKey    = "type"
-	dedKey     = "DED"
 
 	auditType = "audit"
 	logType   = "log"
@@ -28,12 +29,15 @@ const (
 var (
 	stackTraceDepth = 3
 	skipLevel       = 3
-	level           = getEnvOrDefault("LOG_LEVEL", info)
 )
 
 type Config struct {
 	SkipLevel int
-	DED       string
+}
+
+type levelSetter interface {
+	Level() string
+	SetLevel(level string) error
 }
 
 type causer interface {
@@ -46,9 +50,38 @@ type stackTracer interface {
 
 var logger = NewZapLog(info)
 
-// ConfigureLogger configures logger base on env variables
-func ConfigureLogger() {
-	logger = NewZapLog(level)
+// ConfigureLogger configures the logger with the level of the loaded
+// configuration, opts select the sinks.
+func ConfigureLogger(level string, opts ...ZapOption) {
+	logger = NewZapLog(level, opts...)
+}
+
+// SetLogger replaces the configured logger, e.g. with NewSlogLogger.
+func SetLogger(l Logger) {
+	logger = l
+}
+
+// RequestID returns the request ID the request ID middleware stored in the context.
+func RequestID(ctx context.Context) string {
+	return requestid.FromContext(ctx)
+}
+
+// GetLevel returns the level of the configured logger.
+func GetLevel() string {
+	if setter, ok := logger.(levelSetter); ok {
+		return setter.Level()
+	}
+
+	return ""
+}
+
+// SetLevel changes the level of the configured logger at runtime.
+func SetLevel(level string) error {
+	if setter, ok := logger.(levelSetter); ok {
+		return setter.SetLevel(level)
+	}
+
+	return fmt.Errorf("logger doesn't support changing the level")
 }
 
 // Info logs info level
@@ -61,6 +94,11 @@ func Infof(ctx context.Context, format string, args ...interface{}) {
 	logger.Infof(ctx, format, args...)
 }
 
+// Infow logs info level with structured fields
+func Infow(ctx context.Context, msg string, fields ...Field) {
+	logger.Infow(ctx, msg, fields...)
+}
+
 // Warn logs warning level
 func Warn(ctx context.Context, args ...interface{}) {
 	logger.Warn(ctx, args...)
@@ -71,6 +109,11 @@ func Warnf(ctx context.Context, format string, args ...interface{}) {
 	logger.Warnf(ctx, format, args...)
 }
 
+// Warnw logs warning level with structured fields
+func Warnw(ctx context.Context, msg string, fields ...Field) {
+	logger.Warnw(ctx, msg, fields...)
+}
+
 // Debug logs debug level
 func Debug(ctx context.Context, args ...interface{}) {
 	logger.Debug(ctx, args...)
@@ -81,6 +124,11 @@ func Debugf(ctx context.Context, format string, args ...interface{}) {
 	logger.Debugf(ctx, format, args...)
 }
 
+// Debugw logs debug level with structured fields
+func Debugw(ctx context.Context, msg string, fields ...Field) {
+	logger.Debugw(ctx, msg, fields...)
+}
+
 // Error logs error level
 func Error(ctx context.Context, args ...interface{}) {
 	logger.Error(ctx, args...)
@@ -91,6 +139,11 @@ func Errorf(ctx context.Context, format string, args ...interface{}) {
 	logger.Errorf(ctx, format, args...)
 }
 
+// Errorw logs error level with structured fields
+func Errorw(ctx context.Context, msg string, fields ...Field) {
+	logger.Errorw(ctx, msg, fields...)
+}
+
 // Audit logs info level
 func Audit(ctx context.Context, args ...interface{}) {
 	logger.Audit(ctx, args...)
@@ -101,42 +154,61 @@ func Auditf(ctx context.Context, format string, args ...interface{}) {
 	logger.Auditf(ctx, format, args...)
 }
 
+// Auditw logs info level with structured fields
+func Auditw(ctx context.Context, msg string, fields ...Field) {
+	logger.Auditw(ctx, msg, fields...)
+}
+
+// Sync flushes any buffered log entries, call it before the process exits.
+func Sync() error {
+	return logger.Sync()
+}
+
 func createStackTraceMap(err interface{}) []map[string]string {
 	trace := getTopStack(err)
 	if trace == nil {
 		return nil
 	}
 	depth := stackTraceDepth
-	if len(trace) <= stackTraceDepth {
-		depth = len(trace) - 1
+	if len(trace) < stackTraceDepth {
+		depth = len(trace)
 	}
 
+	// the first frame is where the error was created
 	stackTrace := make([]map[string]string, depth)
-	for i := 1; i <= depth; i++ {
+	for i := 0; i < depth; i++ {
 		valued := fmt.Sprintf("%+v", trace[i])
 		valued = strings.Trim(valued, "\n")
 		valued = strings.Replace(valued, "\t", "", -1)
 		stack := strings.Split(valued, "\n")
-		stackTrace[i-1] = map[string]string{"file": stack[1], "func": stack[0]}
+		stackTrace[i] = map[string]string{"file": stack[1], "func": stack[0]}
 	}
 
 	return stackTrace
 }
 
+// getTopStack returns the innermost stack trace of the error chain, following
+// both Cause, as github.com/pkg/errors and Gerror do, and Unwrap.
 func getTopStack(err interface{}) errors.StackTrace {
 	var topStackInfo stackTracer
-	for {
+	for err != nil {
 		stackErr, ok := err.(stackTracer)
 		if ok {
 			topStackInfo = stackErr
 		}
 
-		cause, ok := err.(causer)
+		if cause, ok := err.(causer); ok {
+			err = cause.Cause()
+
+			continue
+		}
+
+		wrapper, ok := err.(error)
 		if !ok {
 			break
 		}
 
-		err = cause.Cause()
+		err = errors.Unwrap(wrapper)
 	}
 
 	if topStackInfo != nil {
@@ -149,4 +221,4 @@ func getTopStack(err interface{}) errors.StackTrace {
 // SetSkipLevel adjust skip level as needed.
 func SetSkipLevel(level int) {
 	skipLevel = level
-}
\ No newline at end of file
+}
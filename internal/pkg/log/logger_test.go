@@ -0,0 +1,32 @@
+package log
+
+import (
+	"context"
+	"testing"
+
+	"catalogue-app/internal/pkg/requestid"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRequestIDField(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	zapLog := &zapLogger{log: zap.New(core), level: zap.NewAtomicLevelAt(zap.InfoLevel), verbose: zap.New(core)}
+
+	zapLog.Infof(requestid.NewContext(context.Background(), "req-42"), "handled %s", "request")
+	zapLog.Info(context.Background(), "startup")
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+
+	if got := entries[0].ContextMap()[reqID]; got != "req-42" {
+		t.Errorf("got %s %v, want req-42", reqID, got)
+	}
+
+	if got := entries[1].ContextMap()[reqID]; got != "" {
+		t.Errorf("got %s %v without a request, want it empty", reqID, got)
+	}
+}
@@ -0,0 +1,286 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"catalogue-app/internal/pkg/validator"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const redacted = "[REDACTED]"
+
+// Built-in patterns masked wherever they appear in messages and string values.
+const (
+	RedactJWT    = "jwt"
+	RedactEmail  = "email"
+	RedactBearer = "bearer"
+)
+
+// DefaultRedactKeys are the field keys whose values are always masked, keys
+// match case-insensitively when they contain one of them.
+var DefaultRedactKeys = []string{"password", "secret", "token", "authorization", "email", "jwt", "recoverykey", "twofa"}
+
+// redactPattern is a built-in pattern. hint cheaply rules out strings the
+// pattern can't match, so that most values never reach the regular expression.
+type redactPattern struct {
+	rx   *regexp.Regexp
+	hint func(s string) bool
+}
+
+var redactPatterns = map[string]redactPattern{
+	RedactJWT: {
+		rx:   regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+		hint: func(s string) bool { return strings.Contains(s, "eyJ") },
+	},
+	RedactEmail: {
+		rx:   unanchored(validator.EmailRX),
+		hint: func(s string) bool { return strings.IndexByte(s, '@') >= 0 },
+	},
+	RedactBearer: {
+		rx:   regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/-]+=*`),
+		hint: func(s string) bool { return containsFold(s, "bearer") },
+	},
+}
+
+// unanchored lets a whole-string pattern match inside longer strings.
+func unanchored(rx *regexp.Regexp) *regexp.Regexp {
+	return regexp.MustCompile(strings.TrimSuffix(strings.TrimPrefix(rx.String(), "^"), "$"))
+}
+
+// Redactor masks personal data and credentials before entries reach a sink.
+type Redactor struct {
+	keys     []string
+	patterns []redactPattern
+}
+
+// NewRedactor returns a Redactor masking the values of fields whose key
+// contains one of keys, and the matches of the named built-in patterns.
+func NewRedactor(keys []string, patterns []string) (*Redactor, error) {
+	redactor := &Redactor{}
+
+	for _, key := range keys {
+		redactor.keys = append(redactor.keys, strings.ToLower(key))
+	}
+
+	for _, name := range patterns {
+		pattern, ok := redactPatterns[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown redaction pattern %q, must be one of jwt, email, bearer", name)
+		}
+
+		redactor.patterns = append(redactor.patterns, pattern)
+	}
+
+	return redactor, nil
+}
+
+// defaultRedactor masks the default keys and all built-in patterns.
+func defaultRedactor() *Redactor {
+	redactor, _ := NewRedactor(DefaultRedactKeys, []string{RedactJWT, RedactEmail, RedactBearer})
+
+	return redactor
+}
+
+// WithRedaction replaces the default redaction, nil disables it.
+func WithRedaction(redactor *Redactor) ZapOption {
+	return func(l *zapLogger) {
+		l.redactor = redactor
+	}
+}
+
+// String masks the pattern matches in s.
+func (redactor *Redactor) String(s string) string {
+	for _, pattern := range redactor.patterns {
+		if pattern.hint(s) {
+			s = pattern.rx.ReplaceAllString(s, redacted)
+		}
+	}
+
+	return s
+}
+
+func (redactor *Redactor) sensitive(key string) bool {
+	for _, sensitive := range redactor.keys {
+		if containsFold(key, sensitive) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsFold reports whether s contains the lower case substr, ignoring
+// case, without allocating a lower case copy of s.
+func containsFold(s string, substr string) bool {
+	if substr == "" {
+		return true
+	}
+
+	// compare only where the first byte matches in either case
+	lower, upper := substr[0], substr[0]
+	if 'a' <= lower && lower <= 'z' {
+		upper -= 'a' - 'A'
+	}
+
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if c := s[i]; (c == lower || c == upper) && strings.EqualFold(s[i:i+len(substr)], substr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Fields masks the fields by key and pattern. Objects and reflected values are
+// flattened to maps so that nested keys such as accessJWT are masked as well.
+// fields is returned as is if nothing needs masking.
+func (redactor *Redactor) Fields(fields []Field) []Field {
+	var redactedFields []Field
+
+	for i, field := range fields {
+		redactedField, changed := redactor.field(field)
+		if !changed {
+			continue
+		}
+
+		if redactedFields == nil {
+			redactedFields = make([]Field, len(fields))
+			copy(redactedFields, fields)
+		}
+
+		redactedFields[i] = redactedField
+	}
+
+	if redactedFields == nil {
+		return fields
+	}
+
+	return redactedFields
+}
+
+// field returns the masked field and whether it differs from field.
+func (redactor *Redactor) field(field Field) (Field, bool) {
+	if field.Type == zapcore.NamespaceType || field.Type == zapcore.SkipType {
+		return field, false
+	}
+
+	if redactor.sensitive(field.Key) {
+		return zap.String(field.Key, redacted), true
+	}
+
+	switch field.Type {
+	case zapcore.StringType:
+		value := redactor.String(field.String)
+		if value == field.String {
+			return field, false
+		}
+
+		return zap.String(field.Key, value), true
+	case zapcore.ErrorType, zapcore.StringerType:
+		value := fmt.Sprint(field.Interface)
+		if masked := redactor.String(value); masked != value {
+			return zap.String(field.Key, masked), true
+		}
+
+		return field, false
+	case zapcore.ReflectType:
+		data, err := json.Marshal(field.Interface)
+		if err != nil {
+			return zap.String(field.Key, redacted), true
+		}
+
+		var value interface{}
+		if err := json.Unmarshal(data, &value); err != nil {
+			return zap.String(field.Key, redacted), true
+		}
+
+		return zap.Any(field.Key, redactor.value(value)), true
+	case zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType, zapcore.ArrayMarshalerType:
+		encoder := zapcore.NewMapObjectEncoder()
+		field.AddTo(encoder)
+
+		if field.Type == zapcore.InlineMarshalerType {
+			return zap.Inline(redactedObject(redactor.value(encoder.Fields).(map[string]interface{}))), true
+		}
+
+		return zap.Any(field.Key, redactor.value(encoder.Fields[field.Key])), true
+	default:
+		return field, false
+	}
+}
+
+func (redactor *Redactor) value(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return redactor.String(v)
+	case map[string]interface{}:
+		redactedMap := make(map[string]interface{}, len(v))
+		for key, member := range v {
+			if redactor.sensitive(key) {
+				redactedMap[key] = redacted
+
+				continue
+			}
+
+			redactedMap[key] = redactor.value(member)
+		}
+
+		return redactedMap
+	case []interface{}:
+		redactedSlice := make([]interface{}, len(v))
+		for i, member := range v {
+			redactedSlice[i] = redactor.value(member)
+		}
+
+		return redactedSlice
+	default:
+		return value
+	}
+}
+
+type redactedObject map[string]interface{}
+
+func (object redactedObject) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	for key, value := range object {
+		zap.Any(key, value).AddTo(encoder)
+	}
+
+	return nil
+}
+
+// redactingCore redacts the message and fields of every entry written to core.
+type redactingCore struct {
+	zapcore.Core
+	redactor *Redactor
+}
+
+func newRedactingCore(core zapcore.Core, redactor *Redactor) zapcore.Core {
+	if redactor == nil {
+		return core
+	}
+
+	return &redactingCore{Core: core, redactor: redactor}
+}
+
+func (core *redactingCore) With(fields []Field) zapcore.Core {
+	return &redactingCore{Core: core.Core.With(core.redactor.Fields(fields)), redactor: core.redactor}
+}
+
+func (core *redactingCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if core.Enabled(entry.Level) {
+		return ce.AddCore(entry, core)
+	}
+
+	return ce
+}
+
+func (core *redactingCore) Write(entry zapcore.Entry, fields []Field) error {
+	entry.Message = core.redactor.String(entry.Message)
+
+	return core.Core.Write(entry, core.redactor.Fields(fields))
+}
@@ -0,0 +1,158 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const rotatedTimeFormat = "20060102T150405.000"
+
+// rotatingFile is a log file rotated by size and age, see RotateConfig.
+type rotatingFile struct {
+	mutex    sync.Mutex
+	cfg      RotateConfig
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+func newRotatingFile(cfg RotateConfig) (*rotatingFile, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("log file output needs a path")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o755); err != nil {
+		return nil, err
+	}
+
+	file := &rotatingFile{cfg: cfg}
+	if err := file.open(); err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// Write appends p, rotating the file first if p would exceed a limit. A failed
+// rotation is reported, but p is still written to the current file.
+func (file *rotatingFile) Write(p []byte) (int, error) {
+	file.mutex.Lock()
+	defer file.mutex.Unlock()
+
+	var rotateErr error
+
+	if file.file == nil {
+		// a previous rotation could not reopen the file
+		if err := file.open(); err != nil {
+			return 0, err
+		}
+	} else if file.shouldRotate(len(p)) {
+		if rotateErr = file.rotate(); file.file == nil {
+			return 0, rotateErr
+		}
+	}
+
+	n, err := file.file.Write(p)
+	file.size += int64(n)
+
+	if err == nil {
+		err = rotateErr
+	}
+
+	return n, err
+}
+
+// Sync flushes the file to disk.
+func (file *rotatingFile) Sync() error {
+	file.mutex.Lock()
+	defer file.mutex.Unlock()
+
+	if file.file == nil {
+		return nil
+	}
+
+	return file.file.Sync()
+}
+
+func (file *rotatingFile) shouldRotate(size int) bool {
+	if file.size == 0 {
+		return false
+	}
+
+	if file.cfg.MaxSize > 0 && file.size+int64(size) > file.cfg.MaxSize {
+		return true
+	}
+
+	return file.cfg.RotateEvery > 0 && time.Since(file.openedAt) >= file.cfg.RotateEvery
+}
+
+func (file *rotatingFile) open() error {
+	f, err := os.OpenFile(file.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+
+		return err
+	}
+
+	file.file, file.size, file.openedAt = f, info.Size(), time.Now()
+
+	return nil
+}
+
+// rotate renames the current file with a timestamp suffix, opens a new one and
+// removes rotated files older than MaxAge. If the rename fails the current file
+// is reopened, file.file is only nil if it can't be opened at all.
+func (file *rotatingFile) rotate() error {
+	closeErr := file.file.Close()
+	file.file = nil
+
+	if closeErr != nil {
+		return errors.Join(closeErr, file.open())
+	}
+
+	rotated := file.cfg.Path + "." + time.Now().Format(rotatedTimeFormat)
+	if err := os.Rename(file.cfg.Path, rotated); err != nil {
+		return errors.Join(err, file.open())
+	}
+
+	if err := file.open(); err != nil {
+		return err
+	}
+
+	file.removeExpired()
+
+	return nil
+}
+
+func (file *rotatingFile) removeExpired() {
+	if file.cfg.MaxAge <= 0 {
+		return
+	}
+
+	matches, err := filepath.Glob(file.cfg.Path + ".*")
+	if err != nil {
+		return
+	}
+
+	for _, match := range matches {
+		stamp := match[len(file.cfg.Path)+1:]
+
+		rotatedAt, err := time.ParseInLocation(rotatedTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+
+		if time.Since(rotatedAt) > file.cfg.MaxAge {
+			os.Remove(match)
+		}
+	}
+}
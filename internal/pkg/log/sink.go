@@ -0,0 +1,92 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Outputs a sink can write to.
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+	OutputSyslog = "syslog"
+)
+
+// SinkConfig selects where a log stream is written, entries go to every output.
+type SinkConfig struct {
+	Outputs []string
+	File    RotateConfig
+
+	// SyslogAddress is network://host:port, empty uses the local syslog socket.
+	SyslogAddress string
+	SyslogTag     string
+}
+
+// RotateConfig configures a rotated log file. The file is rotated when it
+// would exceed MaxSize bytes or is older than RotateEvery, rotated files older
+// than MaxAge are removed. Zero disables the respective limit.
+type RotateConfig struct {
+	Path        string
+	MaxSize     int64
+	RotateEvery time.Duration
+	MaxAge      time.Duration
+}
+
+// OpenSink opens the outputs of the sink, pass the result to WithSink or WithAuditSink.
+func OpenSink(cfg SinkConfig) (zapcore.WriteSyncer, error) {
+	if len(cfg.Outputs) == 0 {
+		return nil, fmt.Errorf("log sink has no outputs")
+	}
+
+	syncers := make([]zapcore.WriteSyncer, 0, len(cfg.Outputs))
+
+	for _, output := range cfg.Outputs {
+		switch strings.ToLower(output) {
+		case OutputStdout:
+			syncers = append(syncers, zapcore.Lock(os.Stdout))
+		case OutputStderr:
+			syncers = append(syncers, zapcore.Lock(os.Stderr))
+		case OutputFile:
+			file, err := newRotatingFile(cfg.File)
+			if err != nil {
+				return nil, err
+			}
+
+			syncers = append(syncers, file)
+		case OutputSyslog:
+			syslog, err := openSyslog(cfg.SyslogAddress, cfg.SyslogTag)
+			if err != nil {
+				return nil, fmt.Errorf("log sink syslog failed %v", err)
+			}
+
+			syncers = append(syncers, syslog)
+		default:
+			return nil, fmt.Errorf("log sink output %q must be one of stdout, stderr, file, syslog", output)
+		}
+	}
+
+	if len(syncers) == 1 {
+		return syncers[0], nil
+	}
+
+	return zapcore.NewMultiWriteSyncer(syncers...), nil
+}
+
+// WithSink writes the log entries to sink instead of stderr.
+func WithSink(sink zapcore.WriteSyncer) ZapOption {
+	return func(l *zapLogger) {
+		l.sink = sink
+	}
+}
+
+// WithAuditSink writes the audit entries to sink instead of the log sink.
+func WithAuditSink(sink zapcore.WriteSyncer) ZapOption {
+	return func(l *zapLogger) {
+		l.auditSink = sink
+	}
+}
@@ -0,0 +1,318 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// recordWriter is implemented by loggers which can write an entry with the
+// time and caller of a slog record instead of their own.
+type recordWriter interface {
+	enabled(ctx context.Context, lvl zapcore.Level) bool
+	writeRecord(ctx context.Context, lvl zapcore.Level, record slog.Record, fields []Field)
+}
+
+// slogHandler is a slog.Handler writing through the configured logger.
+type slogHandler struct {
+	fields []Field
+}
+
+// NewSlogHandler returns a slog.Handler that writes records through the
+// configured logger, with its encoding, levels, scoped levels, request IDs and
+// stack traces. Install it with slog.SetDefault(slog.New(log.NewSlogHandler())).
+func NewSlogHandler() slog.Handler {
+	return slogHandler{}
+}
+
+// Enabled reports whether the global or a scoped level of the context enables level.
+func (handler slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if writer, ok := logger.(recordWriter); ok {
+		return writer.enabled(ctx, zapLevel(level))
+	}
+
+	return true
+}
+
+// Handle writes the record, errors among the attributes add their stack trace.
+func (handler slogHandler) Handle(ctx context.Context, record slog.Record) error {
+	fields := make([]Field, 0, len(handler.fields)+record.NumAttrs()+1)
+	fields = append(fields, handler.fields...)
+
+	var firstErr error
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = appendAttr(fields, attr)
+		if err, ok := attr.Value.Any().(error); ok && firstErr == nil {
+			firstErr = err
+		}
+
+		return true
+	})
+
+	lvl := zapLevel(record.Level)
+	if lvl >= zap.ErrorLevel && firstErr != nil {
+		fields = append(fields, stackTraceFields([]interface{}{firstErr})...)
+	}
+
+	if writer, ok := logger.(recordWriter); ok {
+		writer.writeRecord(ctx, lvl, record, fields)
+
+		return nil
+	}
+
+	switch {
+	case lvl >= zap.ErrorLevel:
+		logger.Errorw(ctx, record.Message, fields...)
+	case lvl >= zap.WarnLevel:
+		logger.Warnw(ctx, record.Message, fields...)
+	case lvl >= zap.InfoLevel:
+		logger.Infow(ctx, record.Message, fields...)
+	default:
+		logger.Debugw(ctx, record.Message, fields...)
+	}
+
+	return nil
+}
+
+// WithAttrs returns a handler adding attrs to every record.
+func (handler slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]Field, 0, len(handler.fields)+len(attrs))
+	fields = append(fields, handler.fields...)
+
+	for _, attr := range attrs {
+		fields = appendAttr(fields, attr)
+	}
+
+	return slogHandler{fields: fields}
+}
+
+// WithGroup returns a handler nesting the attributes added later under name.
+func (handler slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return handler
+	}
+
+	fields := make([]Field, 0, len(handler.fields)+1)
+	fields = append(fields, handler.fields...)
+
+	return slogHandler{fields: append(fields, zap.Namespace(name))}
+}
+
+func (zapLog *zapLogger) enabled(ctx context.Context, lvl zapcore.Level) bool {
+	return zapLog.loggerFor(ctx, logType, lvl).Core().Enabled(lvl)
+}
+
+func (zapLog *zapLogger) writeRecord(ctx context.Context, lvl zapcore.Level, record slog.Record, fields []Field) {
+	ce := zapLog.loggerFor(ctx, logType, lvl).Check(lvl, record.Message)
+	if ce == nil {
+		return
+	}
+
+	if !record.Time.IsZero() {
+		ce.Time = record.Time
+	}
+
+	if record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		ce.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+	}
+
+	ce.Write(entryFields(ctx, logType, fields)...)
+}
+
+// zapLevel maps slog levels to the zap level at or below them.
+func zapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zap.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zap.WarnLevel
+	case level >= slog.LevelInfo:
+		return zap.InfoLevel
+	default:
+		return zap.DebugLevel
+	}
+}
+
+func appendAttr(fields []Field, attr slog.Attr) []Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	value := attr.Value
+
+	switch value.Kind() {
+	case slog.KindString:
+		return append(fields, zap.String(attr.Key, value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(attr.Key, value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(attr.Key, value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(attr.Key, value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(attr.Key, value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(attr.Key, value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(attr.Key, value.Time()))
+	case slog.KindGroup:
+		var group []Field
+		for _, member := range value.Group() {
+			group = appendAttr(group, member)
+		}
+
+		// groups without a key are inlined
+		if attr.Key == "" {
+			return append(fields, group...)
+		}
+
+		if len(group) == 0 {
+			return fields
+		}
+
+		return append(fields, zap.Dict(attr.Key, group...))
+	default:
+		if err, ok := value.Any().(error); ok {
+			return append(fields, zap.NamedError(attr.Key, err))
+		}
+
+		return append(fields, zap.Any(attr.Key, value.Any()))
+	}
+}
+
+// slogLogger is a Logger writing to a slog.Handler.
+type slogLogger struct {
+	handler  slog.Handler
+	redactor *Redactor
+}
+
+// NewSlogLogger returns a Logger writing to handler, install it with SetLogger.
+// The entries carry the same request, tenant, user and context fields as the
+// zap logger and are redacted with the default redaction. Levels are left to
+// the handler, so scoped levels don't apply.
+func NewSlogLogger(handler slog.Handler) Logger {
+	return &slogLogger{handler: handler, redactor: defaultRedactor()}
+}
+
+// Info logs info level
+func (slogLog *slogLogger) Info(ctx context.Context, args ...interface{}) {
+	slogLog.write(ctx, logType, slog.LevelInfo, args, nil)
+}
+
+// Infof logs info level
+func (slogLog *slogLogger) Infof(ctx context.Context, format string, args ...interface{}) {
+	slogLog.writef(ctx, logType, slog.LevelInfo, format, args, nil)
+}
+
+// Infow logs info level with structured fields
+func (slogLog *slogLogger) Infow(ctx context.Context, msg string, fields ...Field) {
+	slogLog.writef(ctx, logType, slog.LevelInfo, msg, nil, fields)
+}
+
+// Warn logs warning level
+func (slogLog *slogLogger) Warn(ctx context.Context, args ...interface{}) {
+	slogLog.write(ctx, logType, slog.LevelWarn, args, nil)
+}
+
+// Warnf logs warning level
+func (slogLog *slogLogger) Warnf(ctx context.Context, format string, args ...interface{}) {
+	slogLog.writef(ctx, logType, slog.LevelWarn, format, args, nil)
+}
+
+// Warnw logs warning level with structured fields
+func (slogLog *slogLogger) Warnw(ctx context.Context, msg string, fields ...Field) {
+	slogLog.writef(ctx, logType, slog.LevelWarn, msg, nil, fields)
+}
+
+// Error logs error level
+func (slogLog *slogLogger) Error(ctx context.Context, args ...interface{}) {
+	slogLog.write(ctx, logType, slog.LevelError, args, stackTraceFields(args))
+}
+
+// Errorf logs error level
+func (slogLog *slogLogger) Errorf(ctx context.Context, format string, args ...interface{}) {
+	slogLog.writef(ctx, logType, slog.LevelError, format, args, stackTraceFields(args))
+}
+
+// Errorw logs error level with structured fields
+func (slogLog *slogLogger) Errorw(ctx context.Context, msg string, fields ...Field) {
+	slogLog.writef(ctx, logType, slog.LevelError, msg, nil, append(fields, errorStackTraceFields(fields)...))
+}
+
+// Debug logs debug level
+func (slogLog *slogLogger) Debug(ctx context.Context, args ...interface{}) {
+	slogLog.write(ctx, logType, slog.LevelDebug, args, nil)
+}
+
+// Debugf logs debug level
+func (slogLog *slogLogger) Debugf(ctx context.Context, format string, args ...interface{}) {
+	slogLog.writef(ctx, logType, slog.LevelDebug, format, args, nil)
+}
+
+// Debugw logs debug level with structured fields
+func (slogLog *slogLogger) Debugw(ctx context.Context, msg string, fields ...Field) {
+	slogLog.writef(ctx, logType, slog.LevelDebug, msg, nil, fields)
+}
+
+// Audit logs info level
+func (slogLog *slogLogger) Audit(ctx context.Context, args ...interface{}) {
+	slogLog.write(ctx, auditType, slog.LevelInfo, args, nil)
+}
+
+// Auditf logs info level
+func (slogLog *slogLogger) Auditf(ctx context.Context, format string, args ...interface{}) {
+	slogLog.writef(ctx, auditType, slog.LevelInfo, format, args, nil)
+}
+
+// Auditw logs info level with structured fields
+func (slogLog *slogLogger) Auditw(ctx context.Context, msg string, fields ...Field) {
+	slogLog.writef(ctx, auditType, slog.LevelInfo, msg, nil, fields)
+}
+
+// Sync does nothing, slog handlers write synchronously.
+func (slogLog *slogLogger) Sync() error {
+	return nil
+}
+
+// write and writef must be called directly by the Logger methods, the caller
+// is taken skipLevel frames up.
+func (slogLog *slogLogger) write(ctx context.Context, lType string, level slog.Level, args []interface{}, fields []Field) {
+	if !slogLog.handler.Enabled(ctx, level) {
+		return
+	}
+
+	slogLog.handle(ctx, lType, level, message(args), fields)
+}
+
+func (slogLog *slogLogger) writef(ctx context.Context, lType string, level slog.Level, format string, args []interface{}, fields []Field) {
+	if !slogLog.handler.Enabled(ctx, level) {
+		return
+	}
+
+	slogLog.handle(ctx, lType, level, messagef(format, args), fields)
+}
+
+func (slogLog *slogLogger) handle(ctx context.Context, lType string, level slog.Level, msg string, fields []Field) {
+	var pcs [1]uintptr
+	// skip runtime.Callers, handle and write
+	runtime.Callers(skipLevel+2, pcs[:])
+
+	record := slog.NewRecord(time.Now(), level, slogLog.redactor.String(msg), pcs[0])
+
+	for _, field := range slogLog.redactor.Fields(entryFields(ctx, lType, fields)) {
+		encoder := zapcore.NewMapObjectEncoder()
+		field.AddTo(encoder)
+
+		for key, value := range encoder.Fields {
+			record.AddAttrs(slog.Any(key, value))
+		}
+	}
+
+	_ = slogLog.handler.Handle(ctx, record)
+}
@@ -0,0 +1,28 @@
+//go:build !windows && !plan9
+
+package log
+
+import (
+	"log/syslog"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// openSyslog connects to the syslog daemon, entries are sent at info priority
+// with the level in the JSON payload.
+func openSyslog(address string, tag string) (zapcore.WriteSyncer, error) {
+	network := ""
+	if address != "" {
+		if scheme, host, ok := strings.Cut(address, "://"); ok {
+			network, address = scheme, host
+		}
+	}
+
+	writer, err := syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_LOCAL0, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return zapcore.AddSync(writer), nil
+}
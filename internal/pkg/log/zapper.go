@@ -2,14 +2,35 @@ package log
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
+	"syscall"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
 type zapLogger struct {
-	log *zap.Logger
+	log   *zap.Logger
+	level zap.AtomicLevel
+
+	// verbose logs at debug level, it is used for entries below the global
+	// level which are enabled by a scoped level, see SetScopedLevel.
+	verbose *zap.Logger
+
+	// audit writes the audit entries regardless of the level, to auditSink
+	// if one is set and to sink otherwise.
+	audit     *zap.Logger
+	sink      zapcore.WriteSyncer
+	auditSink zapcore.WriteSyncer
+
+	// redactor masks personal data and credentials in every stream.
+	redactor *Redactor
+
+	// unsampled disables sampling of the log streams, for benchmarks.
+	unsampled bool
 }
 
 // ZapOption enables extending the default logger.
@@ -17,29 +38,19 @@ type ZapOption func(l *zapLogger)
 
 // NewZapLog returns zap logger which implements Logger interface
 func NewZapLog(level string, opts ...ZapOption) Logger {
-	cfg := zap.NewProductionConfig()
-
-	switch level {
-	case "debug":
-		cfg.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
-	default:
-		cfg.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
+	// unknown levels fall back to info, SetLevel rejects them
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		lvl = zap.InfoLevel
 	}
 
 	cfgE := zap.NewProductionEncoderConfig()
 	cfgE.EncodeTime = zapcore.ISO8601TimeEncoder
 	cfgE.EncodeCaller = zapcore.FullCallerEncoder
-	cfgE.TimeKey = time
+	cfgE.TimeKey = timeKey
 	cfgE.CallerKey = caller
-	cfg.EncoderConfig = cfgE
-	cfg.DisableStacktrace = true
-
-	log, err := cfg.Build(zap.AddCallerSkip(skipLevel))
-	if err != nil {
-		panic(err)
-	}
 
-	zapLog := &zapLogger{log: log}
+	zapLog := &zapLogger{level: zap.NewAtomicLevelAt(lvl), sink: zapcore.Lock(os.Stderr), redactor: defaultRedactor()}
 
 	// process log options
 	for _, o := range opts {
@@ -48,114 +59,243 @@ func NewZapLog(level string, opts ...ZapOption) Logger {
 		}
 	}
 
+	if zapLog.auditSink == nil {
+		zapLog.auditSink = zapLog.sink
+	}
+
+	encoder := zapcore.NewJSONEncoder(cfgE)
+	options := []zap.Option{zap.AddCaller(), zap.AddCallerSkip(skipLevel), zap.ErrorOutput(zapcore.Lock(os.Stderr))}
+
+	// the log streams are sampled like zap's production config, audit entries never are
+	sampled := func(core zapcore.Core) zapcore.Core {
+		if zapLog.unsampled {
+			return core
+		}
+
+		return zapcore.NewSamplerWithOptions(core, time.Second, 100, 100)
+	}
+
+	newCore := func(sink zapcore.WriteSyncer, enabler zapcore.LevelEnabler) zapcore.Core {
+		return newRedactingCore(zapcore.NewCore(encoder, sink, enabler), zapLog.redactor)
+	}
+
+	zapLog.log = zap.New(sampled(newCore(zapLog.sink, zapLog.level)), options...)
+	zapLog.verbose = zap.New(sampled(newCore(zapLog.sink, zap.DebugLevel)), options...)
+	zapLog.audit = zap.New(newCore(zapLog.auditSink, zap.InfoLevel), options...)
+
 	return zapLog
 }
 
+// Level returns the global level.
+func (zapLog *zapLogger) Level() string {
+	return zapLog.level.Level().String()
+}
+
+// SetLevel changes the global level at runtime.
+func (zapLog *zapLogger) SetLevel(level string) error {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	zapLog.level.SetLevel(lvl)
+
+	return nil
+}
+
 // Info use zap log to log info level log
 func (zapLog *zapLogger) Info(ctx context.Context, args ...interface{}) {
-	defer zapLog.log.Sync()
-	zapLog.addAdditionalField(ctx, logType).Sugar().Info(args...)
+	zapLog.write(ctx, logType, zap.InfoLevel, message(args), nil)
 }
 
 // Infof use zap log to log info level log
 func (zapLog *zapLogger) Infof(ctx context.Context, format string, args ...interface{}) {
-	defer zapLog.log.Sync()
-	zapLog.addAdditionalField(ctx, logType).Sugar().Infof(format, args...)
+	zapLog.writef(ctx, logType, zap.InfoLevel, format, args)
+}
+
+// Infow use zap log to log info level log with structured fields
+func (zapLog *zapLogger) Infow(ctx context.Context, msg string, fields ...Field) {
+	zapLog.write(ctx, logType, zap.InfoLevel, msg, fields)
 }
 
 // Warn use zap log to log warning level log
 func (zapLog *zapLogger) Warn(ctx context.Context, args ...interface{}) {
-	defer zapLog.log.Sync()
-	zapLog.addAdditionalField(ctx, logType).Sugar().Warn(args...)
+	zapLog.write(ctx, logType, zap.WarnLevel, message(args), nil)
 }
 
 // Warnf use zap log to log warning level log
 func (zapLog *zapLogger) Warnf(ctx context.Context, format string, args ...interface{}) {
-	defer zapLog.log.Sync()
-	zapLog.addAdditionalField(ctx, logType).Sugar().Warnf(format, args...)
+	zapLog.writef(ctx, logType, zap.WarnLevel, format, args)
+}
+
+// Warnw use zap log to log warning level log with structured fields
+func (zapLog *zapLogger) Warnw(ctx context.Context, msg string, fields ...Field) {
+	zapLog.write(ctx, logType, zap.WarnLevel, msg, fields)
 }
 
 // Error use zap log to log error level log
 func (zapLog *zapLogger) Error(ctx context.Context, args ...interface{}) {
-	defer zapLog.log.Sync()
-	logger := zapLog.addAdditionalField(ctx, logType)
-	zapLog.addStackTrace(logger, args...).Sugar().Error(args...)
+	zapLog.write(ctx, logType, zap.ErrorLevel, message(args), stackTraceFields(args))
 }
 
 // Errorf use zap log to log error level log
 func (zapLog *zapLogger) Errorf(ctx context.Context, format string, args ...interface{}) {
-	defer zapLog.log.Sync()
-	logger := zapLog.addAdditionalField(ctx, logType)
-	zapLog.addStackTrace(logger, args...).Sugar().Errorf(format, args...)
+	zapLog.writef(ctx, logType, zap.ErrorLevel, format, args, stackTraceFields(args)...)
+}
+
+// Errorw use zap log to log error level log with structured fields
+func (zapLog *zapLogger) Errorw(ctx context.Context, msg string, fields ...Field) {
+	zapLog.write(ctx, logType, zap.ErrorLevel, msg, append(fields, errorStackTraceFields(fields)...))
 }
 
 // Debug use zap log to log debug level log
 func (zapLog *zapLogger) Debug(ctx context.Context, args ...interface{}) {
-	defer zapLog.log.Sync()
-	zapLog.addAdditionalField(ctx, logType).Sugar().Debug(args...)
+	zapLog.write(ctx, logType, zap.DebugLevel, message(args), nil)
 }
 
 // Debugf use zap log to log debug level log
 func (zapLog *zapLogger) Debugf(ctx context.Context, format string, args ...interface{}) {
-	defer zapLog.log.Sync()
-	zapLog.addAdditionalField(ctx, logType).Sugar().Debugf(format, args...)
+	zapLog.writef(ctx, logType, zap.DebugLevel, format, args)
+}
+
+// Debugw use zap log to log debug level log with structured fields
+func (zapLog *zapLogger) Debugw(ctx context.Context, msg string, fields ...Field) {
+	zapLog.write(ctx, logType, zap.DebugLevel, msg, fields)
 }
 
 // Audit use zap log to log audit log
 func (zapLog *zapLogger) Audit(ctx context.Context, args ...interface{}) {
-	defer zapLog.log.Sync()
-	zapLog.addAdditionalField(ctx, auditType).Sugar().Info(args...)
+	zapLog.write(ctx, auditType, zap.InfoLevel, message(args), nil)
 }
 
 // Auditf use zap log to log info level log
 func (zapLog *zapLogger) Auditf(ctx context.Context, format string, args ...interface{}) {
-	defer zapLog.log.Sync()
-	zapLog.addAdditionalField(ctx, auditType).Sugar().Infof(format, args...)
+	zapLog.writef(ctx, auditType, zap.InfoLevel, format, args)
+}
+
+// Auditw use zap log to log audit log with structured fields
+func (zapLog *zapLogger) Auditw(ctx context.Context, msg string, fields ...Field) {
+	zapLog.write(ctx, auditType, zap.InfoLevel, msg, fields)
+}
+
+// Sync flushes the zap buffers. Syncing a console or pipe fails with EINVAL or
+// ENOTTY on some platforms, which is not a flush failure.
+func (zapLog *zapLogger) Sync() error {
+	for _, logger := range []*zap.Logger{zapLog.log, zapLog.audit} {
+		if err := logger.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) && !errors.Is(err, syscall.ENOTTY) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// write logs msg if lvl is enabled. Entries are written through Check so
+// that disabled levels cost neither the message nor the fields, and the fields
+// are passed to the entry instead of cloning the core with With.
+func (zapLog *zapLogger) write(ctx context.Context, lType string, lvl zapcore.Level, msg string, fields []Field) {
+	if ce := zapLog.loggerFor(ctx, lType, lvl).Check(lvl, msg); ce != nil {
+		ce.Write(entryFields(ctx, lType, fields)...)
+	}
 }
 
-func getRequestIDFromContext(ctx context.Context) string {
-	rID, ok := ctx.Value("X-Request-ID").(string)
-	if !ok {
-		rID = ""
+// writef is write for printf style messages, which are only formatted for enabled levels.
+func (zapLog *zapLogger) writef(ctx context.Context, lType string, lvl zapcore.Level, format string, args []interface{}, fields ...Field) {
+	logger := zapLog.loggerFor(ctx, lType, lvl)
+	if !logger.Core().Enabled(lvl) {
+		return
 	}
 
-	return rID
+	if ce := logger.Check(lvl, messagef(format, args)); ce != nil {
+		ce.Write(entryFields(ctx, lType, fields)...)
+	}
+}
+
+// entryFields returns the request, tenant, user and context fields followed by the entry fields.
+func entryFields(ctx context.Context, lType string, fields []Field) []Field {
+	ctxFields := fieldsFromContext(ctx)
+	all := make([]Field, 0, 4+len(ctxFields)+len(fields))
+
+	all = append(all, zap.String(reqID, RequestID(ctx)), zap.String(typeKey, lType))
+	if tID := TenantID(ctx); tID != "" {
+		all = append(all, zap.String(tenantID, tID))
+	}
+
+	if uID := UserID(ctx); uID != "" {
+		all = append(all, zap.String(userID, uID))
+	}
+
+	all = createZapFields(all, ctxFields)
+
+	return append(all, fields...)
 }
 
-func (zapLog *zapLogger) addAdditionalField(ctx context.Context, lType string) *zap.Logger {
-	rID := getRequestIDFromContext(ctx)
+// loggerFor returns the audit logger for audit entries, and the verbose logger
+// for entries below the global level enabled by a scoped level matching the context.
+func (zapLog *zapLogger) loggerFor(ctx context.Context, lType string, lvl zapcore.Level) *zap.Logger {
+	if lType == auditType {
+		return zapLog.audit
+	}
 
-	reqField := zap.String(reqID, rID)
-	typeField := zap.String(typeKey, lType)
+	if zapLog.level.Enabled(lvl) {
+		return zapLog.log
+	}
 
-	return zapLog.log.With(reqField).With(typeField)
+	if scoped, ok := scopedLevelFor(ctx); ok && scoped.Enabled(lvl) {
+		return zapLog.verbose
+	}
+
+	return zapLog.log
 }
 
-func (zapLog *zapLogger) createZapFields(fields map[string]interface{}) []interface{} {
-	zapFields := make([]interface{}, 0)
+// createZapFields appends the fields of the map to zapFields.
+func createZapFields(zapFields []Field, fields Fields) []Field {
 	for key, value := range fields {
-		zapFields = append(zapFields, key)
-		zapFields = append(zapFields, value)
+		zapFields = append(zapFields, zap.Any(key, value))
 	}
 
 	return zapFields
 }
 
-func (zapLog *zapLogger) addStackTrace(logger *zap.Logger, args ...interface{}) *zap.Logger {
+// stackTraceFields returns the stack trace field of an error passed as first argument.
+func stackTraceFields(args []interface{}) []Field {
 	if len(args) == 0 {
-		return logger
+		return nil
 	}
+
 	if st := createStackTraceMap(args[0]); st != nil {
-		trace := zap.Any(stackTrace, st)
-		return logger.With(trace)
+		return []Field{zap.Any(stackTrace, st)}
+	}
+
+	return nil
+}
+
+// errorStackTraceFields returns the stack trace field of the first error field.
+func errorStackTraceFields(fields []Field) []Field {
+	for _, field := range fields {
+		if field.Type == zapcore.ErrorType {
+			return stackTraceFields([]interface{}{field.Interface})
+		}
 	}
 
-	return logger
+	return nil
 }
 
-func getEnvOrDefault(envVar string, defaultValue string) string {
-	if v := os.Getenv(envVar); v != "" {
-		return v
+// message formats args the way fmt.Sprint does, without copying a single string argument.
+func message(args []interface{}) string {
+	if len(args) == 1 {
+		if str, ok := args[0].(string); ok {
+			return str
+		}
 	}
-	return defaultValue
-}
\ No newline at end of file
+
+	return fmt.Sprint(args...)
+}
+
+func messagef(format string, args []interface{}) string {
+	if len(args) == 0 {
+		return format
+	}
+
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,75 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"catalogue-app/internal/pkg/requestid"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// newBenchmarkLogger returns an unsampled logger writing to the null device,
+// so that every enabled entry is encoded and written.
+func newBenchmarkLogger(b *testing.B, level string) Logger {
+	b.Helper()
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Cleanup(func() {
+		devNull.Close()
+	})
+
+	return NewZapLog(level, WithSink(zapcore.AddSync(devNull)), func(l *zapLogger) {
+		l.unsampled = true
+	})
+}
+
+func benchmarkContext() context.Context {
+	ctx := requestid.NewContext(context.Background(), "3f2b8c4e-8d1a-4c3e-9a57-2a1e0f6b7c9d")
+	ctx = WithTenantID(ctx, "tenant-1")
+
+	return WithUserID(ctx, "user-1")
+}
+
+func BenchmarkInfof(b *testing.B) {
+	logger := newBenchmarkLogger(b, info)
+	ctx := benchmarkContext()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		logger.Infof(ctx, "movie %d updated in %s", i, "catalogue")
+	}
+}
+
+func BenchmarkDebugfDisabled(b *testing.B) {
+	logger := newBenchmarkLogger(b, info)
+	ctx := benchmarkContext()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		logger.Debugf(ctx, "movie %d updated in %s", i, "catalogue")
+	}
+}
+
+func BenchmarkInfow(b *testing.B) {
+	logger := newBenchmarkLogger(b, info)
+	ctx := benchmarkContext()
+	err := errors.New("duplicate title")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		logger.Infow(ctx, "movie updated", Int("movieID", i), String("resource", "catalogue"), Err(err))
+	}
+}
@@ -2,10 +2,16 @@ package mailer
 
 import (
 	"bytes"
+	"context"
 	"embed"
+	"net"
+	"strconv"
+	"sync/atomic"
 	"text/template"
 	"time"
 
+	"catalogue-app/internal/pkg/requestid"
+
 	"github.com/go-gomail/gomail"
 )
 
@@ -14,27 +20,73 @@ var templateFS embed.FS
 
 type Mailer struct {
 	dialer *gomail.Dialer
-	sender string
+
+	// sender is shared by copies of the Mailer so that it can be changed at runtime.
+	sender *atomic.Value
 }
 
 func New(host string, port int, username string, password string, sender string) Mailer {
 	// Initialize a new mail.Dialer instance with the given SMTP server settings.
 	dialer := gomail.NewDialer(host, port, username, password)
 
-	// Return a Mailer instance containing the dialer and sender information.
-	return Mailer{
+	mailer := Mailer{
 		dialer: dialer,
-		sender: sender,
+		sender: &atomic.Value{},
+	}
+	mailer.SetSender(sender)
+
+	// Return a Mailer instance containing the dialer and sender information.
+	return mailer
+}
+
+// SetSender changes the From address of subsequent messages.
+func (mailer Mailer) SetSender(sender string) {
+	mailer.sender.Store(sender)
+}
+
+// Ping checks that the SMTP server accepts connections, without authenticating.
+func (mailer Mailer) Ping(ctx context.Context) error {
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(mailer.dialer.Host, strconv.Itoa(mailer.dialer.Port)))
+	if err != nil {
+		return err
 	}
+
+	return conn.Close()
 }
 
-// Define a Send() method on the Mailer type.
-func (mailer Mailer) Send(recipient string, templateFile string, data interface{}) error {
+// Define a Send() method on the Mailer type. The request ID of ctx is passed
+// on in the X-Request-ID header of the message.
+func (mailer Mailer) Send(ctx context.Context, recipient string, templateFile string, data interface{}) error {
+	message, err := mailer.newMessage(ctx, recipient, templateFile, data)
+	if err != nil {
+		return err
+	}
+
+	// Call the DialAndSend() method on the dialer, passing in the message to send. This
+	// opens a connection to the SMTP server, sends the message, then closes the
+	// connection.
+	for i := 0; i < 4; i++ {
+		err = mailer.dialer.DialAndSend(message)
+		if err == nil {
+			return nil
+		}
+
+		// Wait 500 millisecond
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	return err
+}
+
+// newMessage renders the template into a message, carrying the request ID of ctx.
+func (mailer Mailer) newMessage(ctx context.Context, recipient string, templateFile string, data interface{}) (*gomail.Message, error) {
 	// Use the ParseFS() method to parse the required template file from the embedded
 	// file system.
 	tmpl, err := template.New("email").ParseFS(templateFS, "templates/"+templateFile)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Execute the named template "subject", passing in the dynamic data and storing the
@@ -42,41 +94,31 @@ func (mailer Mailer) Send(recipient string, templateFile string, data interface{
 	subject := new(bytes.Buffer)
 	err = tmpl.ExecuteTemplate(subject, "subject", data)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	plainBody := new(bytes.Buffer)
 	err = tmpl.ExecuteTemplate(plainBody, "plainBody", data)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	htmlBody := new(bytes.Buffer)
 	err = tmpl.ExecuteTemplate(htmlBody, "htmlBody", data)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Use the mail.NewMessage() function to initialize a new mail.Message instance.
 	message := gomail.NewMessage()
 	message.SetHeader("To", recipient)
-	message.SetHeader("From", mailer.sender)
+	message.SetHeader("From", mailer.sender.Load().(string))
 	message.SetHeader("Subject", subject.String())
+	if id := requestid.FromContext(ctx); id != "" {
+		message.SetHeader(requestid.Header, id)
+	}
 	message.SetBody("text/plain", plainBody.String())
 	message.AddAlternative("text/html", htmlBody.String())
 
-	// Call the DialAndSend() method on the dialer, passing in the message to send. This
-	// opens a connection to the SMTP server, sends the message, then closes the
-	// connection.
-	for i := 0; i < 4; i++ {
-		err = mailer.dialer.DialAndSend(message)
-		if err == nil {
-			return nil
-		}
-
-		// Wait 500 millisecond
-		time.Sleep(500 * time.Millisecond)
-	}
-
-	return err
+	return message, nil
 }
@@ -0,0 +1,40 @@
+package mailer
+
+import (
+	"context"
+	"testing"
+
+	"catalogue-app/internal/pkg/requestid"
+)
+
+func TestNewMessageRequestID(t *testing.T) {
+	mailer := New("localhost", 587, "", "", "noreply@example.com")
+	data := map[string]interface{}{"userID": 7, "activationToken": "token"}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		header []string
+	}{
+		{name: "request", ctx: requestid.NewContext(context.Background(), "req-42"), header: []string{"req-42"}},
+		{name: "no request", ctx: context.Background(), header: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			message, err := mailer.newMessage(test.ctx, "user@example.com", "user_welcome.tmpl", data)
+			if err != nil {
+				t.Fatalf("newMessage failed: %v", err)
+			}
+
+			header := message.GetHeader(requestid.Header)
+			if len(header) != len(test.header) || (len(header) > 0 && header[0] != test.header[0]) {
+				t.Errorf("got %s %v, want %v", requestid.Header, header, test.header)
+			}
+
+			if from := message.GetHeader("From"); len(from) != 1 || from[0] != "noreply@example.com" {
+				t.Errorf("got From %v", from)
+			}
+		})
+	}
+}
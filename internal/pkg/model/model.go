@@ -3,10 +3,34 @@ package model
 import "time"
 
 type MovieInfo struct {
-	ID        int64     `gorm:"primaryKey" json:"id"` // Unique integer ID for movies
-	CreatedAt time.Time `json:"createdAt"`            // Timestamp for creation of a movie
-	UpdatedAt time.Time `json:"updatedAt"`            // Timestamp for updation of a movie
-	Title     string    `json:"title"`                // String title for movie
-	Year      int32     `json:"year,omitempty"`       // Movie release year
-	Genres    []string  `json:"genres,omitempty"`     // Slice of genres for the movie
+	ID        int64     `gorm:"primaryKey" json:"id"`                                                  // Unique integer ID for movies
+	CreatedAt time.Time `json:"createdAt"`                                                             // Timestamp for creation of a movie
+	UpdatedAt time.Time `json:"updatedAt"`                                                             // Timestamp for updation of a movie
+	Title     string    `json:"title" validate:"required,max=500"`                                     // String title for movie
+	Year      int32     `json:"year,omitempty" validate:"required,min=1888"`                           // Movie release year
+	Genres    []string  `json:"genres,omitempty" validate:"required,min=1,max=5,unique,dive,required"` // Slice of genres for the movie
+}
+
+// MoviePatch holds the movie fields of a partial update, nil fields are kept.
+type MoviePatch struct {
+	Title  *string   `json:"title"`
+	Year   *int32    `json:"year"`
+	Genres *[]string `json:"genres"`
+}
+
+// Apply returns movie with the fields set in the patch replaced.
+func (patch MoviePatch) Apply(movie MovieInfo) MovieInfo {
+	if patch.Title != nil {
+		movie.Title = *patch.Title
+	}
+
+	if patch.Year != nil {
+		movie.Year = *patch.Year
+	}
+
+	if patch.Genres != nil {
+		movie.Genres = *patch.Genres
+	}
+
+	return movie
 }
@@ -0,0 +1,53 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+// Header carries the request ID on incoming and outgoing requests.
+const Header = "X-Request-ID"
+
+// validID restricts accepted IDs so that clients can't inject arbitrary content into logs.
+var validID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the request ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID of the context, or an empty string.
+func FromContext(ctx context.Context) string {
+	id, ok := ctx.Value(contextKey{}).(string)
+	if !ok {
+		return ""
+	}
+
+	return id
+}
+
+// Generate returns a new random request ID.
+func Generate() string {
+	return uuid.NewString()
+}
+
+// Accept returns the given ID if it is well formed, otherwise a new one.
+func Accept(id string) string {
+	if validID.MatchString(id) {
+		return id
+	}
+
+	return Generate()
+}
+
+// Inject sets the request ID of the context on an outgoing request's headers.
+func Inject(ctx context.Context, header http.Header) {
+	if id := FromContext(ctx); id != "" {
+		header.Set(Header, id)
+	}
+}
@@ -0,0 +1,112 @@
+package secrets
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EncryptedFile provides secrets from a local AES-256-GCM encrypted YAML file
+// mapping setting names to values, e.g. `DB_PASSWORD: s3cret`.
+type EncryptedFile struct {
+	values map[string]string
+}
+
+// NewEncryptedFile decrypts the file at path with a base64 encoded 32 byte key.
+func NewEncryptedFile(path string, encodedKey string) (*EncryptedFile, error) {
+	key, err := DecodeKey(encodedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := Decrypt(ciphertext, key)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	if err := yaml.Unmarshal(plaintext, &values); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	return &EncryptedFile{values: values}, nil
+}
+
+// Name implements Provider.
+func (*EncryptedFile) Name() string {
+	return "encrypted-file"
+}
+
+// Lookup implements Provider.
+func (file *EncryptedFile) Lookup(_ context.Context, key string) (string, bool, error) {
+	value, found := file.values[key]
+
+	return value, found, nil
+}
+
+// DecodeKey decodes a base64 encoded AES-256 key.
+func DecodeKey(encodedKey string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("secrets key is not valid base64: %w", err)
+	}
+
+	if len(key) != 32 {
+		return nil, errors.New("secrets key must be 32 bytes")
+	}
+
+	return key, nil
+}
+
+// Encrypt seals plaintext with AES-256-GCM, the random nonce is prepended to the result.
+func Encrypt(plaintext []byte, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Decrypt opens ciphertext produced by Encrypt.
+func Decrypt(ciphertext []byte, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+
+	return gcm.Open(nil, nonce, sealed, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,78 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Provider resolves secrets by the environment variable name of the setting
+// they belong to, e.g. DB_PASSWORD. Implementations backed by external vaults
+// plug in here.
+type Provider interface {
+	// Name identifies the provider when reporting where a value came from.
+	Name() string
+
+	// Lookup returns the secret for key, found is false if the provider has none.
+	Lookup(ctx context.Context, key string) (value string, found bool, err error)
+}
+
+// Chain consults its providers in order, the first one holding a secret wins.
+type Chain []Provider
+
+// Lookup returns the secret for key along with the name of the providing provider.
+func (chain Chain) Lookup(ctx context.Context, key string) (string, string, bool, error) {
+	for _, provider := range chain {
+		value, found, err := provider.Lookup(ctx, key)
+		if err != nil {
+			return "", "", false, fmt.Errorf("%s: %w", provider.Name(), err)
+		}
+
+		if found {
+			return value, provider.Name(), true, nil
+		}
+	}
+
+	return "", "", false, nil
+}
+
+// FileEnv reads secrets following the Docker and Kubernetes *_FILE convention:
+// DB_PASSWORD_FILE=/run/secrets/db_password provides DB_PASSWORD.
+type FileEnv struct{}
+
+// Name implements Provider.
+func (FileEnv) Name() string {
+	return "file-env"
+}
+
+// Lookup implements Provider. A trailing newline in the file is stripped.
+func (FileEnv) Lookup(_ context.Context, key string) (string, bool, error) {
+	path, ok := os.LookupEnv(key + "_FILE")
+	if !ok || path == "" {
+		return "", false, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, err
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// Static holds secrets in memory. It stands in for an external vault in tests
+// and local setups.
+type Static map[string]string
+
+// Name implements Provider.
+func (Static) Name() string {
+	return "static"
+}
+
+// Lookup implements Provider.
+func (static Static) Lookup(_ context.Context, key string) (string, bool, error) {
+	value, found := static[key]
+
+	return value, found, nil
+}
@@ -0,0 +1,232 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type failing struct{}
+
+func (failing) Name() string {
+	return "failing"
+}
+
+func (failing) Lookup(context.Context, string) (string, bool, error) {
+	return "", false, errors.New("vault unreachable")
+}
+
+func newKey(t *testing.T) []byte {
+	t.Helper()
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+func writeFile(t *testing.T, content []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestChainLookup(t *testing.T) {
+	chain := Chain{Static{"DB_PASSWORD": "first"}, Static{"DB_PASSWORD": "second", "MAIL_PASSWORD": "mail"}}
+
+	tests := []struct {
+		key   string
+		value string
+		found bool
+	}{
+		{key: "DB_PASSWORD", value: "first", found: true},
+		{key: "MAIL_PASSWORD", value: "mail", found: true},
+		{key: "JWT_ACCESS_KEY", found: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			value, provider, found, err := chain.Lookup(context.Background(), test.key)
+			if err != nil {
+				t.Fatalf("Lookup failed: %v", err)
+			}
+
+			if value != test.value || found != test.found {
+				t.Errorf("got %q found %v, want %q found %v", value, found, test.value, test.found)
+			}
+
+			if found && provider != "static" {
+				t.Errorf("got provider %q, want static", provider)
+			}
+		})
+	}
+}
+
+func TestChainLookupError(t *testing.T) {
+	chain := Chain{Static{}, failing{}, Static{"DB_PASSWORD": "unreached"}}
+
+	_, _, found, err := chain.Lookup(context.Background(), "DB_PASSWORD")
+	if err == nil || err.Error() != "failing: vault unreachable" || found {
+		t.Errorf("got error %v found %v, want the failing provider error", err, found)
+	}
+}
+
+func TestFileEnvLookup(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		value   string
+	}{
+		{name: "plain", content: "s3cret", value: "s3cret"},
+		{name: "trailing newline", content: "s3cret\n", value: "s3cret"},
+		{name: "trailing crlf", content: "s3cret\r\n", value: "s3cret"},
+		{name: "inner whitespace kept", content: " s3 cret \n", value: " s3 cret "},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("DB_PASSWORD_FILE", writeFile(t, []byte(test.content)))
+
+			value, found, err := FileEnv{}.Lookup(context.Background(), "DB_PASSWORD")
+			if err != nil || !found || value != test.value {
+				t.Errorf("got %q found %v error %v, want %q", value, found, err, test.value)
+			}
+		})
+	}
+}
+
+func TestFileEnvLookupUnset(t *testing.T) {
+	t.Setenv("DB_PASSWORD_FILE", "")
+
+	if _, found, err := (FileEnv{}).Lookup(context.Background(), "DB_PASSWORD"); found || err != nil {
+		t.Errorf("got found %v error %v for an empty DB_PASSWORD_FILE", found, err)
+	}
+
+	t.Setenv("DB_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	if _, found, err := (FileEnv{}).Lookup(context.Background(), "DB_PASSWORD"); found || err == nil {
+		t.Errorf("got found %v error %v for a missing file", found, err)
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	key := newKey(t)
+	plaintext := []byte("DB_PASSWORD: s3cret\n")
+
+	ciphertext, err := Encrypt(plaintext, key)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+
+	if bytes.Contains(ciphertext, plaintext) {
+		t.Error("ciphertext contains the plaintext")
+	}
+
+	other, err := Encrypt(plaintext, key)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+
+	if bytes.Equal(ciphertext, other) {
+		t.Error("encrypting twice gave the same ciphertext, the nonce is not random")
+	}
+
+	got, err := Decrypt(ciphertext, key)
+	if err != nil || !bytes.Equal(got, plaintext) {
+		t.Errorf("got %q error %v, want %q", got, err, plaintext)
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	key := newKey(t)
+
+	ciphertext, err := Encrypt([]byte("DB_PASSWORD: s3cret\n"), key)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+
+	tampered := append([]byte(nil), ciphertext...)
+	tampered[len(tampered)-1] ^= 0xff
+
+	tests := []struct {
+		name       string
+		ciphertext []byte
+		key        []byte
+	}{
+		{name: "wrong key", ciphertext: ciphertext, key: newKey(t)},
+		{name: "tampered", ciphertext: tampered, key: key},
+		{name: "short ciphertext", ciphertext: ciphertext[:5], key: key},
+		{name: "empty ciphertext", ciphertext: nil, key: key},
+		{name: "invalid key size", ciphertext: ciphertext, key: key[:7]},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if plaintext, err := Decrypt(test.ciphertext, test.key); err == nil {
+				t.Errorf("Decrypt succeeded with %q", plaintext)
+			}
+		})
+	}
+}
+
+func TestDecodeKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		valid bool
+	}{
+		{name: "valid", key: base64.StdEncoding.EncodeToString(make([]byte, 32)), valid: true},
+		{name: "short", key: base64.StdEncoding.EncodeToString(make([]byte, 16))},
+		{name: "not base64", key: "not a key"},
+		{name: "empty", key: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := DecodeKey(test.key); (err == nil) != test.valid {
+				t.Errorf("got error %v, want valid %v", err, test.valid)
+			}
+		})
+	}
+}
+
+func TestEncryptedFile(t *testing.T) {
+	key := newKey(t)
+	encodedKey := base64.StdEncoding.EncodeToString(key)
+
+	ciphertext, err := Encrypt([]byte("DB_PASSWORD: s3cret\nMAIL_PASSWORD: mail\n"), key)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+
+	path := writeFile(t, ciphertext)
+
+	file, err := NewEncryptedFile(path, encodedKey)
+	if err != nil {
+		t.Fatalf("NewEncryptedFile failed: %v", err)
+	}
+
+	if value, found, _ := file.Lookup(context.Background(), "DB_PASSWORD"); !found || value != "s3cret" {
+		t.Errorf("got %q found %v, want s3cret", value, found)
+	}
+
+	if _, found, _ := file.Lookup(context.Background(), "JWT_ACCESS_KEY"); found {
+		t.Error("JWT_ACCESS_KEY found in the file")
+	}
+
+	if _, err := NewEncryptedFile(path, base64.StdEncoding.EncodeToString(newKey(t))); err == nil {
+		t.Error("NewEncryptedFile succeeded with the wrong key")
+	}
+}
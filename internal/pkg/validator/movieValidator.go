@@ -6,19 +6,10 @@ import (
 )
 
 func MovieValidator(v *Validator, movie *model.MovieInfo) {
-	// Use the Check() method to execute our validation checks.
-	// Title
-	v.Check(movie.Title != "", "title", "must be provided")
-	v.Check(len(movie.Title) <= 500, "title", "must not be more than 500 bytes long")
+	// The rules of the fields are declared in the validate tags of the model,
+	// the messages of the rules are in the i18n catalogs.
+	v.Struct(movie)
 
-	// Year
-	v.Check(movie.Year != 0, "year", "must be provided")
-	v.Check(movie.Year >= 1800, "year", "must be greater than 1888")
-	v.Check(movie.Year <= int32(time.Now().Year()), "year", "invalid year")
-
-	// Genres
-	v.Check(movie.Genres != nil, "genres", "must be provided")
-	v.Check(len(movie.Genres) >= 1, "genres", "must contain at least 1 genre")
-	v.Check(len(movie.Genres) <= 5, "genres", "must not contain more than 5 genres")
-	v.Check(Unique(movie.Genres), "genres", "must not contain duplicate values")
+	// Year, the current year can't be declared in a tag
+	v.CheckRule(movie.Year <= int32(time.Now().Year()), "year", "max", map[string]interface{}{"max": time.Now().Year()})
 }
@@ -0,0 +1,314 @@
+package validator
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RuleFunc reports whether a field value passes a custom rule, param is the
+// text after = in the tag, such as "3" for `validate:"multiple=3"`.
+type RuleFunc func(value reflect.Value, param string) bool
+
+// check is a compiled rule of a field. Name is the rule of the message in the
+// i18n catalogs, which may depend on the kind, min becomes min_bytes for strings.
+type check struct {
+	name   string
+	params map[string]interface{}
+	ok     func(value reflect.Value) bool
+}
+
+// compileFunc compiles the rule with its tag parameter for a field type.
+type compileFunc func(typ reflect.Type, param string) (check, error)
+
+var (
+	rulesMutex sync.RWMutex
+
+	// rules holds the built-in rules and those added with RegisterRule.
+	rules = map[string]compileFunc{
+		"required": compileRequired,
+		"min":      compileMin,
+		"max":      compileMax,
+		"len":      compileLen,
+		"range":    compileRange,
+		"oneof":    compileOneOf,
+		"unique":   compileUnique,
+		"email":    compileEmail,
+		"url":      compileURL,
+		"regex":    compileRegex,
+	}
+
+	// ruleMessages are the English messages of custom rules missing in the
+	// i18n catalogs.
+	ruleMessages = make(map[string]string)
+)
+
+// RegisterRule adds a custom rule for validate tags, message is used when the
+// i18n catalogs have no message for the rule and may contain {field} and
+// {param}. Register rules before validating the types using them, since the
+// rules of a type are compiled once. Registering a name twice, or the name of a
+// built-in rule, is an error.
+func RegisterRule(name string, rule RuleFunc, message string) error {
+	rulesMutex.Lock()
+	defer rulesMutex.Unlock()
+
+	if name == "" || strings.ContainsAny(name, ",= ") {
+		return fmt.Errorf("invalid rule name %q", name)
+	}
+
+	if _, exists := rules[name]; exists || name == diveRule {
+		return fmt.Errorf("rule %s is already registered", name)
+	}
+
+	rules[name] = func(_ reflect.Type, param string) (check, error) {
+		return check{
+			name:   name,
+			params: map[string]interface{}{"param": param},
+			ok: func(value reflect.Value) bool {
+				return rule(value, param)
+			},
+		}, nil
+	}
+
+	ruleMessages[name] = message
+
+	return nil
+}
+
+// MustRegisterRule is RegisterRule for package init functions, it panics so
+// that conflicting rules are detected when the service starts.
+func MustRegisterRule(name string, rule RuleFunc, message string) {
+	if err := RegisterRule(name, rule, message); err != nil {
+		panic(err)
+	}
+}
+
+func lookupRule(name string) (compileFunc, bool) {
+	rulesMutex.RLock()
+	defer rulesMutex.RUnlock()
+
+	compile, ok := rules[name]
+
+	return compile, ok
+}
+
+func ruleMessage(name string) (string, bool) {
+	rulesMutex.RLock()
+	defer rulesMutex.RUnlock()
+
+	message, ok := ruleMessages[name]
+
+	return message, ok
+}
+
+func compileRequired(_ reflect.Type, _ string) (check, error) {
+	return check{name: "required", ok: func(value reflect.Value) bool {
+		return !value.IsZero()
+	}}, nil
+}
+
+func compileMin(typ reflect.Type, param string) (check, error) {
+	return compileBound(typ, "min", param, func(value float64, bound float64) bool {
+		return value >= bound
+	})
+}
+
+func compileMax(typ reflect.Type, param string) (check, error) {
+	return compileBound(typ, "max", param, func(value float64, bound float64) bool {
+		return value <= bound
+	})
+}
+
+func compileLen(typ reflect.Type, param string) (check, error) {
+	return compileBound(typ, "len", param, func(value float64, bound float64) bool {
+		return value == bound
+	})
+}
+
+// compileBound compares numbers by value, strings by their length in bytes and
+// slices, arrays and maps by their number of items.
+func compileBound(typ reflect.Type, name string, param string, compare func(value float64, bound float64) bool) (check, error) {
+	bound, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return check{}, fmt.Errorf("%s needs a number %v", name, err)
+	}
+
+	params := map[string]interface{}{name: param}
+
+	if isNumber(typ.Kind()) {
+		if name == "len" {
+			return check{}, fmt.Errorf("len is not supported for %s", typ)
+		}
+
+		return check{name: name, params: params, ok: func(value reflect.Value) bool {
+			return compare(number(value), bound)
+		}}, nil
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		name += "_bytes"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		name += "_items"
+	default:
+		return check{}, fmt.Errorf("%s is not supported for %s", name, typ)
+	}
+
+	return check{name: name, params: params, ok: func(value reflect.Value) bool {
+		return compare(float64(value.Len()), bound)
+	}}, nil
+}
+
+// compileRange takes the space separated bounds of a number, `validate:"range=1 5"`.
+func compileRange(typ reflect.Type, param string) (check, error) {
+	if !isNumber(typ.Kind()) {
+		return check{}, fmt.Errorf("range is not supported for %s", typ)
+	}
+
+	bounds := strings.Fields(param)
+	if len(bounds) != 2 {
+		return check{}, fmt.Errorf("range needs a minimum and a maximum, got %q", param)
+	}
+
+	min, err := strconv.ParseFloat(bounds[0], 64)
+	if err != nil {
+		return check{}, fmt.Errorf("range minimum failed %v", err)
+	}
+
+	max, err := strconv.ParseFloat(bounds[1], 64)
+	if err != nil {
+		return check{}, fmt.Errorf("range maximum failed %v", err)
+	}
+
+	return check{
+		name:   "range",
+		params: map[string]interface{}{"min": bounds[0], "max": bounds[1]},
+		ok: func(value reflect.Value) bool {
+			n := number(value)
+
+			return n >= min && n <= max
+		},
+	}, nil
+}
+
+// compileOneOf takes the space separated values of a string or number, `validate:"oneof=asc desc"`.
+func compileOneOf(typ reflect.Type, param string) (check, error) {
+	if typ.Kind() != reflect.String && !isNumber(typ.Kind()) {
+		return check{}, fmt.Errorf("oneof is not supported for %s", typ)
+	}
+
+	values := strings.Fields(param)
+
+	return check{
+		name:   "oneof",
+		params: map[string]interface{}{"values": strings.Join(values, ", ")},
+		ok: func(value reflect.Value) bool {
+			return In(fmt.Sprint(value.Interface()), values...)
+		},
+	}, nil
+}
+
+func compileUnique(typ reflect.Type, _ string) (check, error) {
+	if (typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array) || !hashable(typ.Elem()) {
+		return check{}, fmt.Errorf("unique is not supported for %s", typ)
+	}
+
+	return check{name: "unique", ok: func(value reflect.Value) bool {
+		seen := make(map[interface{}]bool, value.Len())
+
+		for i := 0; i < value.Len(); i++ {
+			item := value.Index(i).Interface()
+			if seen[item] {
+				return false
+			}
+
+			seen[item] = true
+		}
+
+		return true
+	}}, nil
+}
+
+// hashable reports whether every value of typ can be a map key. Interfaces are
+// comparable but panic for dynamic values that are not, such as slices.
+func hashable(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Interface:
+		return false
+	case reflect.Array:
+		return hashable(typ.Elem())
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			if !hashable(typ.Field(i).Type) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		return typ.Comparable()
+	}
+}
+
+func compileEmail(typ reflect.Type, _ string) (check, error) {
+	return compileMatch(typ, "email", nil, EmailRX)
+}
+
+// compileURL accepts absolute URLs with a scheme and a host.
+func compileURL(typ reflect.Type, _ string) (check, error) {
+	if typ.Kind() != reflect.String {
+		return check{}, fmt.Errorf("url is not supported for %s", typ)
+	}
+
+	return check{name: "url", ok: func(value reflect.Value) bool {
+		parsed, err := url.ParseRequestURI(value.String())
+
+		return err == nil && parsed.Scheme != "" && parsed.Host != ""
+	}}, nil
+}
+
+// compileRegex takes the rest of the tag as the pattern, so it must be the last rule.
+func compileRegex(typ reflect.Type, param string) (check, error) {
+	rx, err := regexp.Compile(param)
+	if err != nil {
+		return check{}, fmt.Errorf("regex failed %v", err)
+	}
+
+	return compileMatch(typ, "regex", map[string]interface{}{"pattern": param}, rx)
+}
+
+func compileMatch(typ reflect.Type, name string, params map[string]interface{}, rx *regexp.Regexp) (check, error) {
+	if typ.Kind() != reflect.String {
+		return check{}, fmt.Errorf("%s is not supported for %s", name, typ)
+	}
+
+	return check{name: name, params: params, ok: func(value reflect.Value) bool {
+		return Matches(value.String(), rx)
+	}}, nil
+}
+
+func isNumber(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+func number(value reflect.Value) float64 {
+	switch {
+	case value.CanInt():
+		return float64(value.Int())
+	case value.CanUint():
+		return float64(value.Uint())
+	default:
+		return value.Float()
+	}
+}
@@ -0,0 +1,282 @@
+package validator
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// tagName is the struct tag holding the comma separated rules of a field,
+// such as `validate:"required,max=500"`.
+const tagName = "validate"
+
+// diveRule applies the rules following it to the items of a slice or array,
+// their errors are keyed by the item path, such as genres[2].
+const diveRule = "dive"
+
+// typeRules are the compiled rules of a struct type.
+type typeRules struct {
+	fields []fieldRules
+}
+
+// fieldRules are the rules of a field, errors are keyed by its json name.
+// Embedded structs are validated without a prefix, like encoding/json does,
+// unless their type is unexported, since reflect will not return their values.
+type fieldRules struct {
+	index    int
+	name     string
+	embedded bool
+	rules    valueRules
+}
+
+// valueRules are the checks of a value, the rules of its items and those of its
+// struct type.
+type valueRules struct {
+	checks []check
+	items  *valueRules
+	nested *typeRules
+}
+
+var (
+	cacheMutex sync.RWMutex
+	cache      = make(map[reflect.Type]*typeRules)
+)
+
+// Struct validates the fields of a struct, or of a pointer to one, by their
+// validate tags and adds the errors of the failed rules. Nested structs and the
+// struct items of slices are validated too, unless they are nil pointers. Rules
+// other than required are skipped for zero values, so that optional fields may
+// be left out. The rules of a type are compiled on first use, invalid tags panic.
+func (validator *Validator) Struct(value interface{}) {
+	structValue := reflect.ValueOf(value)
+	for structValue.Kind() == reflect.Pointer {
+		if structValue.IsNil() {
+			return
+		}
+
+		structValue = structValue.Elem()
+	}
+
+	if structValue.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validator: Struct needs a struct, got %s", structValue.Type()))
+	}
+
+	validator.validateStruct(structValue, rulesOf(structValue.Type()), "")
+}
+
+func (validator *Validator) validateStruct(value reflect.Value, typeRules *typeRules, prefix string) {
+	for _, field := range typeRules.fields {
+		key := prefix
+		if !field.embedded {
+			key = joinKey(prefix, field.name)
+		}
+
+		validator.validateValue(value.Field(field.index), key, field.rules)
+	}
+}
+
+func (validator *Validator) validateValue(value reflect.Value, key string, rules valueRules) {
+	for value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+
+	zero := value.IsZero()
+
+	for _, check := range rules.checks {
+		if zero && check.name != "required" {
+			continue
+		}
+
+		if !check.ok(value) {
+			validator.CheckRule(false, key, check.name, check.params)
+
+			return
+		}
+	}
+
+	// nil pointers have nothing to descend into
+	if value.Kind() == reflect.Pointer {
+		return
+	}
+
+	if rules.nested != nil {
+		validator.validateStruct(value, rules.nested, key)
+	}
+
+	if rules.items != nil {
+		for i := 0; i < value.Len(); i++ {
+			validator.validateValue(value.Index(i), fmt.Sprintf("%s[%d]", key, i), *rules.items)
+		}
+	}
+}
+
+func joinKey(prefix string, name string) string {
+	if prefix == "" {
+		return name
+	}
+
+	return prefix + "." + name
+}
+
+// rulesOf returns the cached rules of a struct type, compiling them on first use.
+func rulesOf(typ reflect.Type) *typeRules {
+	cacheMutex.RLock()
+	compiled, ok := cache[typ]
+	cacheMutex.RUnlock()
+
+	if ok {
+		return compiled
+	}
+
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	// the types are only cached once all of them compiled
+	pending := make(map[reflect.Type]*typeRules)
+	compiled = compileType(typ, pending)
+
+	for pendingType, pendingRules := range pending {
+		cache[pendingType] = pendingRules
+	}
+
+	return compiled
+}
+
+// compileType compiles the rules of a struct type and of the struct types of
+// its fields. Types being compiled are in pending, so that recursive types end.
+func compileType(typ reflect.Type, pending map[reflect.Type]*typeRules) *typeRules {
+	if compiled, ok := cache[typ]; ok {
+		return compiled
+	}
+
+	if compiled, ok := pending[typ]; ok {
+		return compiled
+	}
+
+	compiled := &typeRules{}
+	pending[typ] = compiled
+
+	for i := 0; i < typ.NumField(); i++ {
+		structField := typ.Field(i)
+
+		tag := structField.Tag.Get(tagName)
+		if !structField.IsExported() || tag == "-" {
+			continue
+		}
+
+		rules, err := compileValue(structField.Type, splitTag(tag), pending)
+		if err != nil {
+			panic(fmt.Sprintf("validator: %s.%s %v", typ, structField.Name, err))
+		}
+
+		if len(rules.checks) == 0 && rules.items == nil && rules.nested == nil {
+			continue
+		}
+
+		compiled.fields = append(compiled.fields, fieldRules{
+			index:    i,
+			name:     fieldName(structField),
+			embedded: structField.Anonymous && rules.nested != nil,
+			rules:    rules,
+		})
+	}
+
+	return compiled
+}
+
+// compileValue compiles the rules of a value of typ, the rules after dive apply
+// to its items.
+func compileValue(typ reflect.Type, ruleTags []string, pending map[reflect.Type]*typeRules) (valueRules, error) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	var rules valueRules
+
+	for i, ruleTag := range ruleTags {
+		name, param, _ := strings.Cut(ruleTag, "=")
+
+		if name == diveRule {
+			if typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array {
+				return valueRules{}, fmt.Errorf("dive is not supported for %s", typ)
+			}
+
+			items, err := compileValue(typ.Elem(), ruleTags[i+1:], pending)
+			if err != nil {
+				return valueRules{}, err
+			}
+
+			rules.items = &items
+
+			break
+		}
+
+		compile, ok := lookupRule(name)
+		if !ok {
+			return valueRules{}, fmt.Errorf("unknown rule %q", name)
+		}
+
+		check, err := compile(typ, param)
+		if err != nil {
+			return valueRules{}, err
+		}
+
+		rules.checks = append(rules.checks, check)
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		rules.nested = compileType(typ, pending)
+	case reflect.Slice, reflect.Array:
+		if rules.items == nil && isStruct(typ.Elem()) {
+			items, err := compileValue(typ.Elem(), nil, pending)
+			if err != nil {
+				return valueRules{}, err
+			}
+
+			rules.items = &items
+		}
+	}
+
+	return rules, nil
+}
+
+// splitTag splits the rules of a tag, the pattern of a regex rule is the rest
+// of the tag since it may contain commas.
+func splitTag(tag string) []string {
+	var ruleTags []string
+
+	for tag != "" {
+		if tag = strings.TrimLeft(tag, " "); strings.HasPrefix(tag, "regex=") {
+			return append(ruleTags, tag)
+		}
+
+		var ruleTag string
+		ruleTag, tag, _ = strings.Cut(tag, ",")
+
+		if ruleTag = strings.TrimSpace(ruleTag); ruleTag != "" {
+			ruleTags = append(ruleTags, ruleTag)
+		}
+	}
+
+	return ruleTags
+}
+
+// fieldName returns the json name of a field, its Go name if it has none.
+func fieldName(structField reflect.StructField) string {
+	name, _, _ := strings.Cut(structField.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return structField.Name
+	}
+
+	return name
+}
+
+func isStruct(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	return typ.Kind() == reflect.Struct
+}
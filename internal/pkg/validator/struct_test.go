@@ -0,0 +1,228 @@
+package validator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testAddress struct {
+	City string `json:"city" validate:"required"`
+	Zip  string `json:"zip" validate:"len=5"`
+}
+
+// TestAudit is exported, embedded structs of unexported types are skipped.
+type TestAudit struct {
+	Owner string `json:"owner" validate:"email"`
+}
+
+type testMovie struct {
+	TestAudit
+
+	Title    string         `json:"title" validate:"required,max=10"`
+	Genres   []string       `json:"genres" validate:"unique,dive,oneof=drama comedy"`
+	Code     string         `json:"code" validate:"regex=^[A-Z]{2,3}(,[0-9]+)?$"`
+	Rating   float64        `json:"rating,omitempty" validate:"range=1 10"`
+	Studio   testAddress    `json:"studio"`
+	Premiere *testAddress   `json:"premiere"`
+	Venues   []*testAddress `json:"venues"`
+	Note     string         `validate:"-"`
+	private  string         `validate:"required"`
+}
+
+func validMovie() testMovie {
+	return testMovie{
+		TestAudit: TestAudit{Owner: "owner@example.com"},
+		Title:     "Heat",
+		Genres:    []string{"drama", "comedy"},
+		Code:      "AB,12",
+		Rating:    8,
+		Studio:    testAddress{City: "Burbank", Zip: "91505"},
+	}
+}
+
+func TestStruct(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(movie *testMovie)
+		errors map[string]string
+	}{
+		{
+			name:   "valid",
+			modify: func(movie *testMovie) {},
+		},
+		{
+			name:   "required",
+			modify: func(movie *testMovie) { movie.Title = "" },
+			errors: map[string]string{"title": "required"},
+		},
+		{
+			name:   "rules after the first failure are skipped",
+			modify: func(movie *testMovie) { movie.Title = strings.Repeat("x", 11) },
+			errors: map[string]string{"title": "max_bytes"},
+		},
+		{
+			name: "zero values skip rules other than required",
+			modify: func(movie *testMovie) {
+				movie.Genres = nil
+				movie.Code = ""
+				movie.Rating = 0
+				movie.Owner = ""
+			},
+		},
+		{
+			name:   "dive keys item errors by index",
+			modify: func(movie *testMovie) { movie.Genres = []string{"drama", "comedy", "horror"} },
+			errors: map[string]string{"genres[2]": "oneof"},
+		},
+		{
+			name:   "unique",
+			modify: func(movie *testMovie) { movie.Genres = []string{"drama", "drama"} },
+			errors: map[string]string{"genres": "unique"},
+		},
+		{
+			name:   "regex with commas",
+			modify: func(movie *testMovie) { movie.Code = "AB;12" },
+			errors: map[string]string{"code": "regex"},
+		},
+		{
+			name:   "range",
+			modify: func(movie *testMovie) { movie.Rating = 11 },
+			errors: map[string]string{"rating": "range"},
+		},
+		{
+			name:   "nested struct",
+			modify: func(movie *testMovie) { movie.Studio.Zip = "123" },
+			errors: map[string]string{"studio.zip": "len_bytes"},
+		},
+		{
+			name:   "zero nested struct is validated",
+			modify: func(movie *testMovie) { movie.Studio = testAddress{} },
+			errors: map[string]string{"studio.city": "required"},
+		},
+		{
+			name:   "nested pointer",
+			modify: func(movie *testMovie) { movie.Premiere = &testAddress{Zip: "10001"} },
+			errors: map[string]string{"premiere.city": "required"},
+		},
+		{
+			name:   "struct items",
+			modify: func(movie *testMovie) { movie.Venues = []*testAddress{nil, {City: "Rome", Zip: "1"}} },
+			errors: map[string]string{"venues[1].zip": "len_bytes"},
+		},
+		{
+			name:   "embedded struct has no prefix",
+			modify: func(movie *testMovie) { movie.Owner = "owner" },
+			errors: map[string]string{"owner": "email"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			movie := validMovie()
+			test.modify(&movie)
+
+			v := New()
+			v.Struct(&movie)
+
+			if len(v.Errors) != len(test.errors) {
+				t.Fatalf("got errors %v, want %v", v.Errors, test.errors)
+			}
+
+			for key, ruleName := range test.errors {
+				if got := v.rules[key].name; got != ruleName {
+					t.Errorf("%s failed %q, want %q: %v", key, got, ruleName, v.Errors)
+				}
+			}
+		})
+	}
+}
+
+func TestStructNilPointer(t *testing.T) {
+	v := New()
+	v.Struct((*testMovie)(nil))
+
+	if !v.Valid() {
+		t.Errorf("nil pointer got errors %v", v.Errors)
+	}
+}
+
+func TestStructInvalidTags(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{name: "unknown rule", value: struct {
+			Title string `validate:"required,shiny"`
+		}{}},
+		{name: "dive of a string", value: struct {
+			Title string `validate:"dive,required"`
+		}{}},
+		{name: "max of a bool", value: struct {
+			Active bool `validate:"max=1"`
+		}{}},
+		{name: "invalid regex", value: struct {
+			Code string `validate:"regex=[a-z"`
+		}{}},
+		{name: "range without maximum", value: struct {
+			Rating int `validate:"range=1"`
+		}{}},
+		{name: "unique of interfaces", value: struct {
+			Tags []interface{} `validate:"unique"`
+		}{}},
+		{name: "unique of structs with interfaces", value: struct {
+			Tags []struct{ Value interface{} } `validate:"unique"`
+		}{}},
+		{name: "unique of slices", value: struct {
+			Tags [][]string `validate:"unique"`
+		}{}},
+		{name: "no struct", value: "title"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Struct accepted %T", test.value)
+				}
+			}()
+
+			New().Struct(test.value)
+		})
+	}
+}
+
+func TestRegisterRule(t *testing.T) {
+	const name = "test_even"
+
+	t.Cleanup(func() {
+		rulesMutex.Lock()
+		defer rulesMutex.Unlock()
+
+		delete(rules, name)
+		delete(ruleMessages, name)
+	})
+
+	even := func(value reflect.Value, _ string) bool {
+		return value.Int()%2 == 0
+	}
+
+	if err := RegisterRule(name, even, "{field} must be even"); err != nil {
+		t.Fatalf("RegisterRule failed: %v", err)
+	}
+
+	for _, duplicate := range []string{name, "required", "regex", diveRule, "", "a,b"} {
+		if err := RegisterRule(duplicate, even, ""); err == nil {
+			t.Errorf("RegisterRule accepted %q", duplicate)
+		}
+	}
+
+	v := New()
+	v.Struct(struct {
+		Count int `json:"count" validate:"test_even"`
+	}{Count: 3})
+
+	if got := v.Errors["count"]; got != "count must be even" {
+		t.Errorf("got message %q", got)
+	}
+}
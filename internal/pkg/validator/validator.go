@@ -1,6 +1,13 @@
 package validator
 
-import "regexp"
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"catalogue-app/internal/pkg/i18n"
+)
 
 // Declare a regular expression for sanity checking the format of email addresses
 var (
@@ -8,14 +15,24 @@ var (
 )
 
 // Define a new Validator type which contains a map of validation errors.
+// Errors holds English messages, rules records the rule of the errors added
+// with CheckRule so that Messages can translate them.
 type Validator struct {
 	Errors map[string]string
+	rules  map[string]rule
+}
+
+// rule is a failed validation rule with its message parameters.
+type rule struct {
+	name   string
+	params map[string]interface{}
 }
 
 // New is a helper which creates a new Validator instance with an empty errors map.
 func New() *Validator {
 	return &Validator{
 		Errors: make(map[string]string),
+		rules:  make(map[string]rule),
 	}
 }
 
@@ -39,6 +56,72 @@ func (validator *Validator) Check(ok bool, key string, message string) {
 	}
 }
 
+// CheckRule adds the message of a validation rule, such as required or
+// max_bytes, to the map only if the check is not 'ok'. The message is looked up
+// in the i18n catalogs with the given parameters and the field name as {field},
+// custom rules fall back to the message they were registered with.
+func (validator *Validator) CheckRule(ok bool, key string, ruleName string, params map[string]interface{}) {
+	if ok {
+		return
+	}
+
+	if _, exist := validator.Errors[key]; exist {
+		return
+	}
+
+	withField := map[string]interface{}{"field": key}
+	for name, value := range params {
+		withField[name] = value
+	}
+
+	message, found := i18n.Validation(i18n.English, ruleName, withField)
+	if !found {
+		message = formatRuleMessage(ruleName, withField)
+	}
+
+	validator.AddError(key, message)
+
+	if validator.rules == nil {
+		validator.rules = make(map[string]rule)
+	}
+
+	validator.rules[key] = rule{name: ruleName, params: withField}
+}
+
+// Messages returns the errors in the language of the context, messages added
+// without a rule are returned as is.
+func (validator *Validator) Messages(ctx context.Context) map[string]string {
+	lang := i18n.Language(ctx)
+	messages := make(map[string]string, len(validator.Errors))
+
+	for key, message := range validator.Errors {
+		messages[key] = message
+
+		if rule, ok := validator.rules[key]; ok {
+			if translated, found := i18n.Validation(lang, rule.name, rule.params); found {
+				messages[key] = translated
+			}
+		}
+	}
+
+	return messages
+}
+
+// formatRuleMessage returns the message of a custom rule missing in the i18n
+// catalogs, the rule name if it has none.
+func formatRuleMessage(ruleName string, params map[string]interface{}) string {
+	message, ok := ruleMessage(ruleName)
+	if !ok {
+		return ruleName
+	}
+
+	for name, value := range params {
+		message = strings.ReplaceAll(message, "{"+name+"}", fmt.Sprint(value))
+	}
+
+	return message
+}
+
 // In returns true if a specific value is in a list of strings.
 func In(value string, list ...string) bool {
 	for _, vl := range list {
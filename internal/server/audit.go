@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"catalogue-app/internal/config"
+	db "catalogue-app/internal/database"
+	"catalogue-app/internal/pkg/audit"
+
+	"gorm.io/gorm"
+)
+
+// newAuditRecorder returns the hash chain of the configured store, or the
+// audit log stream if no store is configured.
+func newAuditRecorder(auditConf config.AuditConfig, gormDB *gorm.DB) audit.Recorder {
+	store := auditStore(auditConf, gormDB)
+	if store == nil {
+		return audit.LogRecorder{}
+	}
+
+	return audit.NewChain(store, []byte(auditConf.HMACKey), auditConf.SignEvery)
+}
+
+func auditStore(auditConf config.AuditConfig, gormDB *gorm.DB) audit.Store {
+	switch auditConf.Store {
+	case "file":
+		return audit.NewFileStore(auditConf.File)
+	case "db":
+		return db.NewAuditStore(gormDB)
+	default:
+		return nil
+	}
+}
+
+// VerifyAudit verifies the audit chain of the configured store and returns the
+// number of records in it.
+func VerifyAudit(ctx context.Context, cfg *config.Configuration) (uint64, error) {
+	var gormDB *gorm.DB
+
+	switch cfg.AuditConf.Store {
+	case "":
+		return 0, fmt.Errorf("no audit store configured, set AUDIT_STORE")
+	case "db":
+		dbClient := db.NewDBClient(cfg)
+
+		var err error
+		if gormDB, err = dbClient.DBInit(); err != nil {
+			return 0, fmt.Errorf("database connection failed %v", err)
+		}
+
+		if err := dbClient.WaitForDB(ctx, gormDB); err != nil {
+			return 0, fmt.Errorf("database connection failed %v", err)
+		}
+	}
+
+	return audit.Verify(ctx, auditStore(cfg.AuditConf, gormDB), []byte(cfg.AuditConf.HMACKey), cfg.AuditConf.SignEvery)
+}
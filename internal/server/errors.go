@@ -0,0 +1,26 @@
+package server
+
+import (
+	"net/http"
+
+	gerror "catalogue-app/internal/pkg/error"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errorCatalogHandler lists the registered error codes.
+func errorCatalogHandler(ginCtx *gin.Context) {
+	ginCtx.JSON(http.StatusOK, gin.H{"errors": gerror.Catalog()})
+}
+
+// errorCodeHandler describes a single error code, problem types point here.
+func errorCodeHandler(ginCtx *gin.Context) {
+	info, ok := gerror.Lookup(gerror.ErrorCode(ginCtx.Param("code")))
+	if !ok {
+		gerror.RespondWithError(ginCtx, gerror.New(gerror.NotFound, "unknown error code"), "Unknown error code")
+
+		return
+	}
+
+	ginCtx.JSON(http.StatusOK, info)
+}
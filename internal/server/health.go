@@ -0,0 +1,59 @@
+package server
+
+import (
+	"net/http"
+
+	db "catalogue-app/internal/database"
+	"catalogue-app/internal/pkg/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerHealthChecks registers the built-in dependency checks backing /readyz.
+func (app *AppServerBase) registerHealthChecks() {
+	svcConfig := app.config.SvcConfig
+	app.health = health.NewRegistry(svcConfig.HealthCheckTimeout, svcConfig.HealthCacheTTL)
+
+	app.health.Register(health.Check{Name: "database", Fn: db.PingCheck(app.db), Critical: true})
+
+	if version := app.config.DBConfig.SchemaVersion; version > 0 {
+		app.health.Register(health.Check{Name: "migrations", Fn: db.SchemaVersionCheck(app.db, version), Critical: true})
+	}
+
+	app.health.Register(health.Check{Name: "smtp", Fn: app.mailer.Ping})
+
+	sslConfig := app.config.DBConfig.SslConfig
+	if sslConfig.Sslmode == "verify-ca" || sslConfig.Sslmode == "verify-full" {
+		app.health.Register(health.Check{
+			Name: "certificates",
+			Fn:   health.CertExpiry(svcConfig.CertExpiryThreshold, sslConfig.RootCA, sslConfig.ServerCert, sslConfig.ClientCert),
+		})
+	}
+}
+
+// livez reports that the process is up and serving, it doesn't check dependencies.
+func (app *AppServerBase) livez(ginCtx *gin.Context) {
+	ginCtx.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+// readyz reports whether the service can accept traffic, which requires the
+// startup wait to be complete, no shutdown in progress and all critical checks passing.
+func (app *AppServerBase) readyz(ginCtx *gin.Context) {
+	if !app.ready.Load() {
+		ginCtx.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": health.StatusFail,
+			"reason": "service starting or shutting down",
+		})
+
+		return
+	}
+
+	report := app.health.Run(ginCtx.Request.Context())
+	if !report.Healthy() {
+		ginCtx.JSON(http.StatusServiceUnavailable, report)
+
+		return
+	}
+
+	ginCtx.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"catalogue-app/internal/pkg/lifecycle"
+	"catalogue-app/internal/pkg/log"
+)
+
+// registerLifecycleHooks registers the server components. They are stopped in
+// reverse order: readiness flips off and the drain delay passes, in-flight
+// requests drain, background workers stop, then the database is closed and the
+// logs are flushed.
+func (app *AppServerBase) registerLifecycleHooks() {
+	app.lifecycle.Append(lifecycle.Hook{
+		Name: "logger",
+		OnStop: func(ctx context.Context) error {
+			return log.Sync()
+		},
+	})
+
+	app.lifecycle.Append(lifecycle.Hook{
+		Name: "database",
+		OnStop: func(ctx context.Context) error {
+			sqlDB, err := app.db.DB()
+			if err != nil {
+				return err
+			}
+
+			return sqlDB.Close()
+		},
+	})
+
+	app.lifecycle.Append(lifecycle.Hook{
+		Name: "workers",
+		OnStart: func(ctx context.Context) error {
+			app.workerCtx, app.stopWorkers = context.WithCancel(context.Background())
+			app.goWorker(app.waitForDB)
+			app.goWorker(app.watchReloadSignal)
+
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			app.stopWorkers()
+
+			done := make(chan struct{})
+			go func() {
+				app.workers.Wait()
+				close(done)
+			}()
+
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+
+	app.lifecycle.Append(lifecycle.Hook{
+		Name: "http server",
+		OnStart: func(ctx context.Context) error {
+			app.startGinServer()
+
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			err := app.server.Shutdown(ctx)
+
+			// cancel requests still running after the shutdown deadline
+			app.cancelBase()
+
+			return err
+		},
+	})
+
+	app.lifecycle.Append(lifecycle.Hook{
+		Name: "readiness",
+		OnStop: func(ctx context.Context) error {
+			// stop receiving new traffic before draining in-flight requests, the
+			// drain delay gives load balancers time to notice the failing /readyz
+			app.ready.Store(false)
+
+			drainDelay := time.NewTimer(app.config.SvcConfig.DrainDelay)
+			defer drainDelay.Stop()
+
+			select {
+			case <-drainDelay.C:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+}
+
+// goWorker runs fn in a background goroutine which is stopped and waited for
+// during shutdown.
+func (app *AppServerBase) goWorker(fn func(ctx context.Context)) {
+	app.workers.Add(1)
+
+	go func() {
+		defer app.workers.Done()
+		fn(app.workerCtx)
+	}()
+}
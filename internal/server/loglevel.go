@@ -0,0 +1,75 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	gerror "catalogue-app/internal/pkg/error"
+	"catalogue-app/internal/pkg/log"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultScopedLevelTTL applies to scoped levels set without a ttl.
+const defaultScopedLevelTTL = 15 * time.Minute
+
+// logLevelRequest changes the global level, or the level of a single request
+// or user when RequestID or UserID is set. An empty scoped level removes it.
+type logLevelRequest struct {
+	Level     string `json:"level"`
+	RequestID string `json:"requestID,omitempty"`
+	UserID    string `json:"userID,omitempty"`
+	TTL       string `json:"ttl,omitempty"`
+}
+
+type logLevelResponse struct {
+	Level  string            `json:"level"`
+	Scoped []log.ScopedLevel `json:"scoped"`
+}
+
+func logLevelHandler(ginCtx *gin.Context) {
+	ginCtx.JSON(http.StatusOK, logLevelResponse{Level: log.GetLevel(), Scoped: log.ScopedLevels()})
+}
+
+func setLogLevelHandler(ginCtx *gin.Context) {
+	var request logLevelRequest
+
+	if err := ginCtx.ShouldBindJSON(&request); err != nil {
+		gerror.RespondWithError(ginCtx, gerror.NewFromError(gerror.FailedUnmarshalling, err), "")
+
+		return
+	}
+
+	if err := applyLogLevel(request); err != nil {
+		gerror.RespondWithError(ginCtx, gerror.NewFromError(gerror.BadRequest, err), err.Error())
+
+		return
+	}
+
+	log.Auditf(ginCtx.Request.Context(), "log level changed by %s: %+v", ginCtx.ClientIP(), request)
+
+	logLevelHandler(ginCtx)
+}
+
+func applyLogLevel(request logLevelRequest) error {
+	scope := log.Scope{RequestID: request.RequestID, UserID: request.UserID}
+	if scope == (log.Scope{}) {
+		return log.SetLevel(request.Level)
+	}
+
+	if request.Level == "" {
+		log.RemoveScopedLevel(scope)
+
+		return nil
+	}
+
+	ttl := defaultScopedLevelTTL
+	if request.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(request.TTL); err != nil {
+			return err
+		}
+	}
+
+	return log.SetScopedLevel(scope, request.Level, ttl)
+}
@@ -1,13 +1,21 @@
 package server
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/rsa"
+	"crypto/subtle"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	gerror "catalogue-app/internal/pkg/error"
+	"catalogue-app/internal/pkg/i18n"
+	"catalogue-app/internal/pkg/log"
+	"catalogue-app/internal/pkg/requestid"
+
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
@@ -37,10 +45,11 @@ var JWTParams JWTParameters
 
 // MyCustomClaims ...
 type MyCustomClaims struct {
-	AuthID uint64 `json:"authID,omitempty"`
-	Email  string `json:"email,omitempty"`
-	Role   string `json:"role,omitempty"`
-	Scope  string `json:"scope,omitempty"`
+	AuthID   uint64 `json:"authID,omitempty"`
+	TenantID string `json:"tenantID,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Role     string `json:"role,omitempty"`
+	Scope    string `json:"scope,omitempty"`
 }
 
 // JWTClaims ...
@@ -57,7 +66,9 @@ type JWTPayload struct {
 	RecoveryKey string `json:"recoveryKey,omitempty"`
 }
 
-// JWT - validate access token
+// JWT - validate access token, the cookie takes precedence over the
+// Authorization header. The tenant and user of a valid token are stored in the
+// request context so that they are logged with every entry of the request.
 func JWTConfiguration() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var jwtPayload JWTPayload
@@ -69,49 +80,69 @@ func JWTConfiguration() gin.HandlerFunc {
 		// accessJWT is available in the cookie
 		if err == nil {
 			jwtPayload.AccessJWT = accessJWT
-			errVal := verifyClaims(c, jwtPayload)
-
-			if errVal != nil {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, "failed to verify claims")
-
+		} else {
+			// accessJWT is not available in the cookie
+			// try to read the Authorization header
+			val = c.Request.Header.Get("Authorization")
+			if len(val) == 0 || !strings.Contains(val, "Bearer") {
+				// no vals or no bearer found
+				c.AbortWithStatusJSON(http.StatusUnauthorized, "token missing")
+				return
+			}
+			vals = strings.Split(val, " ")
+			// Authorization: Bearer {access} => length is 2
+			// Authorization: Bearer {access} {refresh} => length is 3
+			if len(vals) < 2 {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, "token missing")
 				return
 			}
-		}
 
-		// accessJWT is not available in the cookie
-		// try to read the Authorization header
-		val = c.Request.Header.Get("Authorization")
-		if len(val) == 0 || !strings.Contains(val, "Bearer") {
-			// no vals or no bearer found
-			c.AbortWithStatusJSON(http.StatusUnauthorized, "token missing")
-			return
+			jwtPayload.AccessJWT = vals[1]
 		}
-		vals = strings.Split(val, " ")
-		// Authorization: Bearer {access} => length is 2
-		// Authorization: Bearer {access} {refresh} => length is 3
-		if len(vals) < 2 {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, "token missing")
+
+		claims, err := verifyClaims(jwtPayload)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, "failed to verify claims")
+
 			return
 		}
 
-		jwtPayload.AccessJWT = vals[1]
+		c.Request = c.Request.WithContext(withIdentity(c.Request.Context(), claims))
+		c.Next()
 	}
 }
 
-func verifyClaims(c *gin.Context, jwtPayload JWTPayload) error {
+func verifyClaims(jwtPayload JWTPayload) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(jwtPayload.AccessJWT, &JWTClaims{}, ValidateAccessJWT)
 	if err != nil {
-		return fmt.Errorf("error is : %w", err)
+		return nil, fmt.Errorf("error is : %w", err)
 	}
 
-	_, ok := token.Claims.(*JWTClaims)
+	claims, ok := token.Claims.(*JWTClaims)
 	if !ok {
-		return fmt.Errorf("error is : %w", err)
+		return nil, fmt.Errorf("unexpected claims type %T", token.Claims)
 	}
 
-	c.Next()
+	return claims, nil
+}
 
-	return nil
+// withIdentity stores the tenant and user of the claims in the context, the
+// user is the auth ID, or the subject for tokens without one.
+func withIdentity(ctx context.Context, claims *JWTClaims) context.Context {
+	if claims.TenantID != "" {
+		ctx = log.WithTenantID(ctx, claims.TenantID)
+	}
+
+	userID := claims.Subject
+	if claims.AuthID != 0 {
+		userID = strconv.FormatUint(claims.AuthID, 10)
+	}
+
+	if userID != "" {
+		ctx = log.WithUserID(ctx, userID)
+	}
+
+	return ctx
 }
 
 // ValidateHMACAccess - validate hash based access token
@@ -172,10 +203,11 @@ func GetJWT(customClaims MyCustomClaims, tokenType string) (string, string, erro
 	// Create the Claims
 	claims := JWTClaims{
 		MyCustomClaims{
-			AuthID: customClaims.AuthID,
-			Email:  customClaims.Email,
-			Role:   customClaims.Role,
-			Scope:  customClaims.Scope,
+			AuthID:   customClaims.AuthID,
+			TenantID: customClaims.TenantID,
+			Email:    customClaims.Email,
+			Role:     customClaims.Role,
+			Scope:    customClaims.Scope,
 		},
 		jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute * time.Duration(ttl))),
@@ -284,11 +316,19 @@ func GetJWT(customClaims MyCustomClaims, tokenType string) (string, string, erro
 	return jwtValue, claims.ID, nil
 }
 
-func Cors() gin.HandlerFunc {
+// Cors allows the origins returned by allowedOrigins, which is called on every
+// request so that the origins can change at runtime. "*" allows any origin.
+func Cors(allowedOrigins func() []string) gin.HandlerFunc {
 	return func(ginCtx *gin.Context) {
 		// First, we add the headers with need to enable CORS.
 		// Make sure to adjust these headers to your needs.
-		ginCtx.Header("Access-Control-Allow-Origin", "*")
+		origin := ginCtx.GetHeader("Origin")
+		for _, allowed := range allowedOrigins() {
+			if allowed == "*" || allowed == origin {
+				ginCtx.Header("Access-Control-Allow-Origin", allowed)
+				break
+			}
+		}
 		// ginCtx.Header("Access-Control-Allow-Methods", "*")
 		// ginCtx.Header("Access-Control-Allow-Headers", "*")
 		ginCtx.Header("Content-Type", "application/json")
@@ -297,3 +337,50 @@ func Cors() gin.HandlerFunc {
 		ginCtx.Next()
 	}
 }
+
+// RequestID accepts the X-Request-ID of the request or generates one, stores it
+// in the request context for logging and outgoing calls, and echoes it in the response.
+func RequestID() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		id := requestid.Accept(ginCtx.GetHeader(requestid.Header))
+
+		ginCtx.Request = ginCtx.Request.WithContext(requestid.NewContext(ginCtx.Request.Context(), id))
+		ginCtx.Header(requestid.Header, id)
+
+		ginCtx.Next()
+	}
+}
+
+// Localize stores the language best matching the Accept-Language header in the
+// request context, error and validation messages are translated to it.
+func Localize() gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		lang := i18n.Match(ginCtx.GetHeader("Accept-Language"))
+		ginCtx.Request = ginCtx.Request.WithContext(i18n.WithLanguage(ginCtx.Request.Context(), lang))
+
+		ginCtx.Next()
+	}
+}
+
+// AdminAuth authenticates admin endpoints with a static bearer token, the
+// endpoints are disabled when no token is configured.
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		if token == "" {
+			gerror.RespondWithError(ginCtx, gerror.New(gerror.Forbidden, "admin endpoints disabled"), "Admin endpoints are disabled")
+			ginCtx.Abort()
+
+			return
+		}
+
+		provided := strings.TrimPrefix(ginCtx.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			gerror.RespondWithError(ginCtx, gerror.New(gerror.Unauthorized, "invalid admin token"), "")
+			ginCtx.Abort()
+
+			return
+		}
+
+		ginCtx.Next()
+	}
+}
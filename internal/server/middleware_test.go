@@ -0,0 +1,113 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gerror "catalogue-app/internal/pkg/error"
+	"catalogue-app/internal/pkg/log"
+	"catalogue-app/internal/pkg/requestid"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		incoming string
+		kept     bool
+	}{
+		{name: "valid", incoming: "req-42.a:b_c", kept: true},
+		{name: "uuid", incoming: "0f8fad5b-d9cb-469f-a165-70867728950e", kept: true},
+		{name: "longest", incoming: strings.Repeat("a", 128), kept: true},
+		{name: "missing", incoming: ""},
+		{name: "overlong", incoming: strings.Repeat("a", 129)},
+		{name: "log injection", incoming: "req\n{\"level\":\"error\"}"},
+		{name: "spaces", incoming: "req 42"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var logged string
+
+			router := gin.New()
+			router.Use(RequestID())
+			router.GET("/", func(ginCtx *gin.Context) {
+				// the log entries of the request carry the ID of its context
+				logged = log.RequestID(ginCtx.Request.Context())
+			})
+
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.incoming != "" {
+				request.Header.Set(requestid.Header, test.incoming)
+			}
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			id := recorder.Header().Get(requestid.Header)
+			if test.kept && id != test.incoming {
+				t.Errorf("got %s %q, want the incoming %q", requestid.Header, id, test.incoming)
+			}
+
+			if !test.kept && (id == "" || id == test.incoming) {
+				t.Errorf("got %s %q, want a generated ID", requestid.Header, id)
+			}
+
+			if logged != id {
+				t.Errorf("logged request ID %q differs from the response %q", logged, id)
+			}
+		})
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		acceptLanguage  string
+		contentLanguage string
+		title           string
+	}{
+		{acceptLanguage: "de", contentLanguage: "de", title: "Ressource nicht gefunden"},
+		{acceptLanguage: "de-AT", contentLanguage: "de", title: "Ressource nicht gefunden"},
+		{acceptLanguage: "fr", contentLanguage: "en", title: "Resource not found"},
+		{acceptLanguage: "", contentLanguage: "en", title: "Resource not found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.acceptLanguage, func(t *testing.T) {
+			router := gin.New()
+			router.Use(Localize())
+			router.GET("/", func(ginCtx *gin.Context) {
+				gerror.RespondWithError(ginCtx, gerror.New(gerror.NotFound, "no movie"), "")
+			})
+
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.acceptLanguage != "" {
+				request.Header.Set("Accept-Language", test.acceptLanguage)
+			}
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			if got := recorder.Header().Get("Content-Language"); got != test.contentLanguage {
+				t.Errorf("got Content-Language %q, want %q", got, test.contentLanguage)
+			}
+
+			var problem gerror.Problem
+			if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("response %s is not a problem: %v", recorder.Body, err)
+			}
+
+			if problem.Title != test.title {
+				t.Errorf("got title %q, want %q", problem.Title, test.title)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	gerror "catalogue-app/internal/pkg/error"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBuckets bounds the number of tracked clients, idle buckets are swept
+// once it is exceeded.
+const maxBuckets = 10000
+
+// rateLimiter is a per-client token bucket limiter whose limits can be
+// changed at runtime.
+type rateLimiter struct {
+	mutex   sync.Mutex
+	limit   float64 // tokens per second, zero disables limiting
+	burst   float64
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(limit int, burst int) *rateLimiter {
+	limiter := &rateLimiter{buckets: make(map[string]*bucket)}
+	limiter.SetLimit(limit, burst)
+
+	return limiter
+}
+
+// SetLimit changes the limits, existing buckets keep their tokens up to the new burst.
+func (limiter *rateLimiter) SetLimit(limit int, burst int) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	limiter.limit = float64(limit)
+	limiter.burst = float64(burst)
+}
+
+// allow takes a token from the client's bucket, if none is left it returns
+// false and the time until the next token is available.
+func (limiter *rateLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	if limiter.limit <= 0 {
+		return true, 0
+	}
+
+	clientBucket, ok := limiter.buckets[client]
+	if !ok {
+		if len(limiter.buckets) >= maxBuckets {
+			limiter.sweep(now)
+		}
+
+		clientBucket = &bucket{tokens: limiter.burst, last: now}
+		limiter.buckets[client] = clientBucket
+	}
+
+	elapsed := now.Sub(clientBucket.last).Seconds()
+	clientBucket.tokens = math.Min(limiter.burst, clientBucket.tokens+elapsed*limiter.limit)
+	clientBucket.last = now
+
+	if clientBucket.tokens < 1 {
+		wait := time.Duration((1 - clientBucket.tokens) / limiter.limit * float64(time.Second))
+
+		return false, wait
+	}
+
+	clientBucket.tokens--
+
+	return true, 0
+}
+
+// sweep drops buckets that have refilled completely, they hold no state.
+func (limiter *rateLimiter) sweep(now time.Time) {
+	for client, clientBucket := range limiter.buckets {
+		if clientBucket.tokens+now.Sub(clientBucket.last).Seconds()*limiter.limit >= limiter.burst {
+			delete(limiter.buckets, client)
+		}
+	}
+}
+
+// RateLimit rejects requests of clients exceeding the limiter's rate with a 429.
+func RateLimit(limiter *rateLimiter) gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		allowed, wait := limiter.allow(ginCtx.ClientIP(), time.Now())
+		if !allowed {
+			ginCtx.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			gerror.RespondWithError(ginCtx, gerror.New(gerror.RateLimited, "rate limit exceeded"), "")
+			ginCtx.Abort()
+
+			return
+		}
+
+		ginCtx.Next()
+	}
+}
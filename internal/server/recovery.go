@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"catalogue-app/internal/pkg/crash"
+	gerror "catalogue-app/internal/pkg/error"
+	"catalogue-app/internal/pkg/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// crashReportTimeout bounds handing a crash report to the sink.
+const crashReportTimeout = 5 * time.Second
+
+var panicsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "panics_total",
+	Help: "Number of panics recovered while serving requests.",
+})
+
+func init() {
+	prometheus.MustRegister(panicsTotal)
+}
+
+// SetCrashSink replaces the crash report file sink, call it before
+// ConfigureAndStart. Reports are deduplicated by fingerprint within window.
+func (app *AppServerBase) SetCrashSink(sink crash.Sink, window time.Duration) {
+	app.crashSink = crash.Deduplicate(sink, window)
+}
+
+// Recovery recovers panics of the handlers. The panic is logged with the
+// request ID and stack, counted in panics_total and reported to sink, and the
+// client receives an internal server error through RespondWithError.
+func Recovery(sink crash.Sink) gin.HandlerFunc {
+	return func(ginCtx *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			// net/http aborts the response silently for this panic
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			ctx := ginCtx.Request.Context()
+			report := crash.NewReport(ctx, recovered, ginCtx.Request.Method, ginCtx.FullPath())
+			err := gerror.Newf(gerror.InternalServerError, "panic: %v", recovered)
+
+			panicsTotal.Inc()
+			log.Error(log.WithFields(ctx, log.Fields{"fingerprint": report.Fingerprint, "stack": report.Stack}), err)
+
+			if sink != nil {
+				// the request context may be cancelled already
+				reportCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), crashReportTimeout)
+				if err := sink.Report(reportCtx, report); err != nil {
+					log.Warnf(ctx, "unable to report crash %s: %v", report.Fingerprint, err)
+				}
+				cancel()
+			}
+
+			if ginCtx.Writer.Written() {
+				ginCtx.Abort()
+
+				return
+			}
+
+			gerror.RespondWithError(ginCtx, err, "")
+			ginCtx.Abort()
+		}()
+
+		ginCtx.Next()
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"catalogue-app/internal/config"
+	gerror "catalogue-app/internal/pkg/error"
+	"catalogue-app/internal/pkg/features"
+	"catalogue-app/internal/pkg/log"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reloadResult reports the settings changed by a reload.
+type reloadResult struct {
+	Applied         []config.Change `json:"applied"`
+	RestartRequired []config.Change `json:"restartRequired"`
+}
+
+// currentConfig returns the configuration in effect, which changes on reload.
+func (app *AppServerBase) currentConfig() *config.Configuration {
+	app.configMutex.RLock()
+	defer app.configMutex.RUnlock()
+
+	return app.config
+}
+
+// applyRuntimeConfig applies the settings that can change without a restart,
+// previous is nil at startup. LOG_LEVEL is only applied when it changed, so
+// that a reload keeps a level set through /support/loglevel.
+func (app *AppServerBase) applyRuntimeConfig(previous *config.Configuration, cfg *config.Configuration) {
+	if previous == nil || previous.SvcConfig.LogLevel != cfg.SvcConfig.LogLevel {
+		if err := log.SetLevel(cfg.SvcConfig.LogLevel); err != nil {
+			log.Warnf(context.Background(), "unable to set log level: %v", err)
+		}
+	}
+
+	app.corsOrigins.Store(cfg.SvcConfig.CorsOriginList())
+	app.limiter.SetLimit(cfg.SvcConfig.RateLimit, cfg.SvcConfig.RateBurst)
+	app.mailer.SetSender(cfg.MailConf.Sender)
+	features.Set(cfg.SvcConfig.FeatureList())
+}
+
+func (app *AppServerBase) allowedOrigins() []string {
+	return app.corsOrigins.Load().([]string)
+}
+
+// reload re-reads the configuration and applies the runtime-safe settings.
+// Changed settings that need a restart are reported but not applied. Every
+// reload is recorded as an audit event with the diff.
+func (app *AppServerBase) reload(ctx context.Context, actor string) (reloadResult, error) {
+	app.reloadMutex.Lock()
+	defer app.reloadMutex.Unlock()
+
+	current := app.currentConfig()
+
+	reloaded, err := current.Reload()
+	if err != nil {
+		log.Errorf(ctx, "configuration reload by %s failed: %v", actor, err)
+
+		return reloadResult{}, err
+	}
+
+	result := reloadResult{Applied: []config.Change{}, RestartRequired: []config.Change{}}
+	for _, change := range current.Diff(reloaded) {
+		if change.Reloadable {
+			result.Applied = append(result.Applied, change)
+		} else {
+			result.RestartRequired = append(result.RestartRequired, change)
+		}
+	}
+
+	applied := current.WithReloadable(reloaded)
+	app.applyRuntimeConfig(current, applied)
+
+	app.configMutex.Lock()
+	app.config = applied
+	app.configMutex.Unlock()
+
+	diff, err := json.Marshal(result)
+	if err != nil {
+		return result, err
+	}
+
+	log.Auditf(ctx, "configuration reloaded by %s: %s", actor, diff)
+
+	if len(result.RestartRequired) > 0 {
+		log.Warnf(ctx, "configuration changes need a restart to take effect: %s", diff)
+	}
+
+	return result, nil
+}
+
+// watchReloadSignal reloads the configuration on every signal passed to
+// ReloadOn until ctx is done.
+func (app *AppServerBase) watchReloadSignal(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-app.reloadSignals:
+			_, _ = app.reload(ctx, "SIGHUP")
+		}
+	}
+}
+
+// reloadHandler reloads the configuration on behalf of an authenticated admin.
+func (app *AppServerBase) reloadHandler(ginCtx *gin.Context) {
+	result, err := app.reload(ginCtx.Request.Context(), "admin endpoint "+ginCtx.ClientIP())
+	if err != nil {
+		gerror.RespondWithError(ginCtx, gerror.NewFromError(gerror.BadRequest, err), "Configuration reload failed: "+err.Error())
+
+		return
+	}
+
+	ginCtx.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReloadOnSignal(t *testing.T) {
+	app := newTestServer(t)
+
+	signals := make(chan os.Signal, 1)
+	app.ReloadOn(signals)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stopped := make(chan struct{})
+
+	go func() {
+		app.watchReloadSignal(ctx)
+		close(stopped)
+	}()
+
+	t.Setenv("DEV_MODE", "true")
+	t.Setenv("HTTP_RATE_LIMIT", "7")
+	t.Setenv("HTTP_CORS_ORIGINS", "https://example.com")
+	t.Setenv("DB_URL", "db.example.com")
+
+	signals <- syscall.SIGHUP
+
+	for deadline := time.Now().Add(5 * time.Second); app.currentConfig().SvcConfig.RateLimit != 7; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("configuration not reloaded on SIGHUP")
+		}
+	}
+
+	cfg := app.currentConfig()
+
+	if origins := app.allowedOrigins(); len(origins) != 1 || origins[0] != "https://example.com" {
+		t.Errorf("got CORS origins %v, want the reloaded one", origins)
+	}
+
+	if cfg.DBConfig.Url != "127.0.0.1" {
+		t.Errorf("DB_URL applied without a restart: %s", cfg.DBConfig.Url)
+	}
+
+	cancel()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watchReloadSignal didn't return after its context was cancelled")
+	}
+}
@@ -4,17 +4,25 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
-	"os/signal"
 	"sync"
-	"syscall"
-	"time"
+	"sync/atomic"
 
+	"catalogue-app/internal/config"
+	"catalogue-app/internal/controller"
+	db "catalogue-app/internal/database"
+	"catalogue-app/internal/handler"
+	"catalogue-app/internal/pkg/crash"
+	"catalogue-app/internal/pkg/health"
+	"catalogue-app/internal/pkg/lifecycle"
 	"catalogue-app/internal/pkg/log"
+	"catalogue-app/internal/pkg/mailer"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gorm.io/gorm"
 )
 
 type AppServerBase struct {
@@ -23,40 +31,208 @@ type AppServerBase struct {
 	name      string
 	isRunning bool
 	mutex     sync.Mutex
+	db        *gorm.DB
+	dbClient  db.DBClient
+	health    *health.Registry
+	mailer    mailer.Mailer
+	limiter   *rateLimiter
+
+	// crashSink receives the reports of recovered panics.
+	crashSink crash.Sink
+
+	// config is replaced on reload, read it through currentConfig once serving.
+	config      *config.Configuration
+	configMutex sync.RWMutex
+	reloadMutex sync.Mutex
+	corsOrigins atomic.Value
+
+	// reloadSignals triggers a configuration reload, see ReloadOn.
+	reloadSignals <-chan os.Signal
+
+	// ready reports whether the service can accept traffic, it stays false
+	// until the database is reachable.
+	ready atomic.Bool
+
+	// baseCtx is the parent of every request context, it is cancelled once
+	// shutdown completes so that in-flight database operations are aborted.
+	baseCtx    context.Context
+	cancelBase context.CancelFunc
+
+	lifecycle *lifecycle.Manager
+	fatal     chan error
+
+	// background workers, stopped before the database is closed
+	workers     sync.WaitGroup
+	workerCtx   context.Context
+	stopWorkers context.CancelFunc
 }
 
 // New implements AppServerBase.
-func New(name string) *AppServerBase {
-	return &AppServerBase{name: name}
+func New(name string, cfg *config.Configuration) *AppServerBase {
+	return &AppServerBase{
+		name:      name,
+		config:    cfg,
+		lifecycle: lifecycle.New(),
+		fatal:     make(chan error, 1),
+	}
+}
+
+// ReloadOn reloads the configuration whenever a signal is received on signals.
+// The caller registers the channel with signal.Notify before starting, so that
+// a SIGHUP arriving during startup is not lost or kills the process.
+func (app *AppServerBase) ReloadOn(signals <-chan os.Signal) {
+	app.reloadSignals = signals
+}
+
+// configureLogger opens the log and audit sinks, sets the redaction of the
+// configuration and the configured level.
+func configureLogger(level string, logConf config.LogConfig) (*log.Redactor, error) {
+	sink, err := log.OpenSink(logConf.LogSink())
+	if err != nil {
+		return nil, fmt.Errorf("log sink failed %v", err)
+	}
+
+	redactor, err := logConf.Redactor()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []log.ZapOption{log.WithSink(sink), log.WithRedaction(redactor)}
+
+	if auditConf, ok := logConf.AuditSink(); ok {
+		auditSink, err := log.OpenSink(auditConf)
+		if err != nil {
+			return nil, fmt.Errorf("audit sink failed %v", err)
+		}
+
+		opts = append(opts, log.WithAuditSink(auditSink))
+	}
+
+	log.ConfigureLogger(level, opts...)
+
+	return redactor, nil
+}
+
+func (app *AppServerBase) ConfigureAndStart(ctx context.Context) error {
+	if err := app.Init(); err != nil {
+		return err
+	}
+
+	if err := app.connectDB(); err != nil {
+		return err
+	}
+
+	app.registerHealthChecks()
+
+	app.setupAPIWithRouter(ctx)
+
+	return app.Start(ctx)
 }
 
-func configureLogger() {
-	log.ConfigureLogger()
+func (app *AppServerBase) connectDB() error {
+	app.dbClient = db.NewDBClient(app.config)
+
+	dbVal, err := app.dbClient.DBInit()
+	if err != nil {
+		return fmt.Errorf("database connection failed %v", err)
+	}
+
+	app.db = dbVal
+
+	return nil
 }
 
-func (app *AppServerBase) ConfigureAndStart() {
-	app.Init()
-	app.setupAPIWithRouter(context.Background())
-	app.Start()
+// waitForDB marks the service ready once the database is reachable, the
+// server shuts down if it is not reachable before the configured deadline.
+func (app *AppServerBase) waitForDB(ctx context.Context) {
+	if err := app.dbClient.WaitForDB(ctx, app.db); err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			// shutting down
+			return
+		}
+
+		app.fail(fmt.Errorf("giving up waiting for database: %w", err))
+
+		return
+	}
+
+	app.ready.Store(true)
 }
 
-func (app *AppServerBase) Init() {
-	configureLogger()
+func (app *AppServerBase) Init() error {
+	redactor, err := configureLogger(app.config.SvcConfig.LogLevel, app.config.LogConf)
+	if err != nil {
+		return err
+	}
+
+	app.configureJWT()
+
+	mailConf := app.config.MailConf
+	app.mailer = mailer.New(mailConf.Host, mailConf.Port, mailConf.Username, mailConf.Password, mailConf.Sender)
+	app.limiter = newRateLimiter(app.config.SvcConfig.RateLimit, app.config.SvcConfig.RateBurst)
+	app.applyRuntimeConfig(nil, app.config)
+
+	if app.crashSink == nil {
+		app.SetCrashSink(crash.NewFileSink(app.config.SvcConfig.CrashReportDir), app.config.SvcConfig.CrashDedupWindow)
+	}
 
 	app.Router = gin.New()
 	gin.EnableJsonDecoderDisallowUnknownFields()
-	app.Router.Use(gin.Recovery())
+	app.Router.Use(RequestID())
+	app.Router.Use(Localize())
+	// reports are masked like the log, the panic value may hold request data
+	app.Router.Use(Recovery(crash.Redact(app.crashSink, redactor.String)))
 	app.Router.HandleMethodNotAllowed = true
 	// Enabling Cors to allow your browser access the API.
-	app.Router.Use(Cors())
+	app.Router.Use(Cors(app.allowedOrigins))
+
+	// probes are registered before the rate limiter so that they are never throttled
+	app.Router.GET("/livez", app.livez)
+	app.Router.GET("/readyz", app.readyz)
+
+	app.Router.Use(RateLimit(app.limiter))
 
 	app.Router.GET("/status", func(c *gin.Context) {
+		if !app.ready.Load() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"service": "NOT_READY",
+			})
+
+			return
+		}
+
 		c.JSON(http.StatusOK, gin.H{
 			"service": "OK",
 		})
 	})
 
+	app.Router.GET("/v1/errors", errorCatalogHandler)
+	app.Router.GET("/v1/errors/:code", errorCodeHandler)
+
 	app.Router.GET("/support/metrics", prometheusHandler())
+	app.Router.GET("/support/config", AdminAuth(app.config.SvcConfig.AdminToken), app.configHandler)
+	app.Router.POST("/support/reload", AdminAuth(app.config.SvcConfig.AdminToken), app.reloadHandler)
+	app.Router.GET("/support/loglevel", AdminAuth(app.config.SvcConfig.AdminToken), logLevelHandler)
+	app.Router.PUT("/support/loglevel", AdminAuth(app.config.SvcConfig.AdminToken), setLogLevelHandler)
+
+	return nil
+}
+
+// configureJWT sets the JWT parameters from the auth configuration.
+func (app *AppServerBase) configureJWT() {
+	authConf := app.config.AuthConf
+
+	JWTParams.Algorithm = authConf.Algorithm
+	JWTParams.AccessKey = []byte(authConf.AccessKey)
+	JWTParams.AccessKeyTTL = authConf.AccessKeyTTL
+	JWTParams.Issuer = authConf.Issuer
+	JWTParams.Audience = authConf.Audience
+}
+
+// configHandler shows admins the effective configuration and the source of
+// every value, with passwords and keys redacted.
+func (app *AppServerBase) configHandler(ginCtx *gin.Context) {
+	ginCtx.JSON(http.StatusOK, app.currentConfig().Settings())
 }
 
 func prometheusHandler() gin.HandlerFunc {
@@ -67,38 +243,72 @@ func (app *AppServerBase) setupAPIWithRouter(ctx context.Context) {
 
 	router := app.Router.Group("catalogue")
 	router.POST("/getData", JWTConfiguration(), nil)
+
+	movieHandler := handler.NewMovieHandler(
+		controller.NewMovieController(db.NewClient(app.db, app.config.DBConfig.QueryTimeout),
+			newAuditRecorder(app.config.AuditConf, app.db)))
+
+	router.GET("/movies", movieHandler.GetMovies)
+	router.POST("/movies", movieHandler.CreateMovie)
+	router.GET("/movies/:id", movieHandler.GetMovieByID)
+	router.PUT("/movies/:id", movieHandler.UpdateMovie)
+	router.PATCH("/movies/:id", movieHandler.PatchMovie)
+	router.DELETE("/movies/:id", movieHandler.DeleteMovie)
 }
 
-// Start starts the Server for real.
-func (app *AppServerBase) Start() {
-	log.Infof(context.Background(), "Starting %s Server...", app.name)
-	app.startGinServer()
-	log.Infof(context.Background(), "%s server started successfully ...", app.name)
+// Start starts all components and blocks until ctx is done or a component
+// fails, then stops them within ShutdownWait.
+func (app *AppServerBase) Start(ctx context.Context) error {
+	app.registerLifecycleHooks()
+
+	log.Infof(ctx, "Starting %s Server...", app.name)
+
+	if err := app.lifecycle.Start(ctx); err != nil {
+		return err
+	}
+
+	log.Infof(ctx, "%s server started successfully ...", app.name)
+
+	var runErr error
+
+	select {
+	case <-ctx.Done():
+	case runErr = <-app.fatal:
+		log.Errorf(context.Background(), "%s server failed: %v", app.name, runErr)
+	}
 
-	// Wait for interrupt signal to gracefully shutdown the server with
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
 	log.Infof(context.Background(), "Shutting down %s server...", app.name)
-	app.StopServer()
+
+	if err := app.StopServer(); err != nil && runErr == nil {
+		runErr = err
+	}
+
+	return runErr
 }
 
 func (app *AppServerBase) startGinServer() {
+	app.baseCtx, app.cancelBase = context.WithCancel(context.Background())
+	svcConfig := app.config.SvcConfig
 	app.server = &http.Server{
-		Addr:              fmt.Sprintf(":%d", 9002),
+		Addr:              fmt.Sprintf(":%d", svcConfig.Port),
 		Handler:           app.Router,
-		ReadHeaderTimeout: time.Second * time.Duration(20),
+		ReadHeaderTimeout: svcConfig.HeaderReadTimeout,
+		ReadTimeout:       svcConfig.ReadTimeout,
+		WriteTimeout:      svcConfig.WriteTimeout,
+		IdleTimeout:       svcConfig.IdleTimeout,
+		BaseContext: func(net.Listener) context.Context {
+			return app.baseCtx
+		},
 	}
 	// Initializing the server in a goroutine so that
-	// it won't block the graceful shutdown handling below
+	// it won't block the graceful shutdown handling
 	go func() {
 		app.mutex.Lock()
 		app.isRunning = true
 		app.mutex.Unlock()
 
 		if err := app.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Errorf(context.Background(), "listen: %s\n", err)
-			os.Exit(1)
+			app.fail(fmt.Errorf("listen: %w", err))
 		}
 
 		app.mutex.Lock()
@@ -107,15 +317,26 @@ func (app *AppServerBase) startGinServer() {
 	}()
 }
 
-func (app *AppServerBase) StopServer() {
-	// The context is used to inform the server it has 5 seconds to finish
-	// the request it is currently handling
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(5)*time.Second)
+// StopServer stops all started components in reverse start order, the whole
+// sequence is bounded by ShutdownWait.
+func (app *AppServerBase) StopServer() error {
+	ctx, cancel := context.WithTimeout(context.Background(), app.currentConfig().SvcConfig.ShutdownWait)
 	defer cancel()
 
-	if err := app.server.Shutdown(ctx); err != nil {
-		log.Errorf(context.Background(), "Server Shutdown: %v", err)
+	if err := app.lifecycle.Stop(ctx); err != nil {
+		return err
 	}
 
 	log.Info(context.Background(), "Server stopped successfully ...")
+
+	return nil
+}
+
+// fail reports a fatal component error to Start, which then shuts down.
+func (app *AppServerBase) fail(err error) {
+	select {
+	case app.fatal <- err:
+	default:
+		// a failure is already being handled
+	}
 }
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"catalogue-app/internal/config"
+)
+
+const testDrainDelay = 200 * time.Millisecond
+
+// newTestServer returns a server whose database is never reachable, so that
+// it stays not ready while it runs.
+func newTestServer(t *testing.T) *AppServerBase {
+	t.Helper()
+
+	cfg := &config.Configuration{
+		DBConfig: config.DatabaseConfig{
+			Username:            "root",
+			Url:                 "127.0.0.1",
+			Port:                "1",
+			DbName:              "testing",
+			SslConfig:           config.SSLConfig{Sslmode: "disable"},
+			MaxOpenConns:        1,
+			ConnectTimeout:      time.Hour,
+			ConnectBackoffStart: 10 * time.Millisecond,
+			ConnectBackoffMax:   10 * time.Millisecond,
+		},
+		SvcConfig: config.ServiceConfig{
+			Port:              freePort(t),
+			ShutdownWait:      5 * time.Second,
+			DrainDelay:        testDrainDelay,
+			HeaderReadTimeout: time.Second,
+		},
+		LogConf: config.LogConfig{Output: "stderr"},
+	}
+
+	app := New("test", cfg)
+	if err := app.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.connectDB(); err != nil {
+		t.Fatal(err)
+	}
+
+	return app
+}
+
+// freePort returns a port that was free a moment ago.
+func freePort(t *testing.T) uint {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	return uint(listener.Addr().(*net.TCPAddr).Port)
+}
+
+// waitForListener polls url until the server answers.
+func waitForListener(t *testing.T, url string) {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if resp, err := http.Get(url); err == nil {
+			resp.Body.Close()
+
+			return
+		}
+	}
+
+	t.Fatalf("server didn't start listening on %s", url)
+}
+
+func TestStartReturnsAfterDrainOnCancel(t *testing.T) {
+	app := newTestServer(t)
+	baseURL := fmt.Sprintf("http://127.0.0.1:%d", app.config.SvcConfig.Port)
+	livez := baseURL + "/livez"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- app.Start(ctx)
+	}()
+
+	waitForListener(t, livez)
+
+	app.ready.Store(true)
+	cancel()
+	cancelled := time.Now()
+
+	// during the drain the server keeps answering but reports not ready
+	time.Sleep(testDrainDelay / 4)
+
+	resp, err := http.Get(baseURL + "/status")
+	if err != nil {
+		t.Fatalf("server stopped answering during the drain: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("got status %d during the drain, want %d", resp.StatusCode, http.StatusServiceUnavailable)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Start failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start didn't return after its context was cancelled")
+	}
+
+	if elapsed := time.Since(cancelled); elapsed < testDrainDelay {
+		t.Errorf("Start returned %s after the cancel, before the %s drain delay", elapsed, testDrainDelay)
+	}
+
+	if app.ready.Load() {
+		t.Error("server is still ready after shutdown")
+	}
+
+	if resp, err := http.Get(livez); err == nil {
+		resp.Body.Close()
+		t.Error("server still accepts connections after shutdown")
+	}
+}
@@ -1,9 +1,130 @@
-package main 
+package main
 
-import(
+import (
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"catalogue-app/internal/config"
+	"catalogue-app/internal/pkg/secrets"
+	"catalogue-app/internal/server"
 )
 
+const usage = `usage:
+  catalogue-app [flags]               start the server
+  catalogue-app config print [flags]  print the effective configuration
+  catalogue-app secrets encrypt       encrypt a YAML secrets file from stdin to stdout with SECRETS_KEY or SECRETS_KEY_FILE
+  catalogue-app audit verify [flags]  verify the hash chain of the audit store`
+
 func main() {
-	fmt.Println("lets start the game")
-}
\ No newline at end of file
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	if len(args) > 0 && args[0] == "config" {
+		if len(args) < 2 || args[1] != "print" {
+			return fmt.Errorf("unknown config command\n%s", usage)
+		}
+
+		return printConfig(args[2:])
+	}
+
+	if len(args) > 0 && args[0] == "secrets" {
+		if len(args) < 2 || args[1] != "encrypt" {
+			return fmt.Errorf("unknown secrets command\n%s", usage)
+		}
+
+		return encryptSecrets()
+	}
+
+	if len(args) > 0 && args[0] == "audit" {
+		if len(args) < 2 || args[1] != "verify" {
+			return fmt.Errorf("unknown audit command\n%s", usage)
+		}
+
+		return verifyAudit(args[2:])
+	}
+
+	return serve(args)
+}
+
+func serve(args []string) error {
+	cfg, err := config.Load(args)
+	if err != nil {
+		return fmt.Errorf("%v\n%s", err, usage)
+	}
+
+	// cancelled on interrupt to gracefully shutdown the server
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// registered before starting, SIGHUP would terminate the process otherwise
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
+
+	app := server.New("catalogue", cfg)
+	app.ReloadOn(reload)
+
+	return app.ConfigureAndStart(ctx)
+}
+
+func printConfig(args []string) error {
+	cfg, err := config.Load(args)
+	if err != nil {
+		return err
+	}
+
+	return cfg.Print(os.Stdout)
+}
+
+func verifyAudit(args []string) error {
+	cfg, err := config.Load(args)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	count, err := server.VerifyAudit(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("audit chain verification failed after %d records: %v", count, err)
+	}
+
+	fmt.Printf("audit chain verified, %d records\n", count)
+
+	return nil
+}
+
+func encryptSecrets() error {
+	encodedKey, err := config.SecretsKey()
+	if err != nil {
+		return err
+	}
+
+	key, err := secrets.DecodeKey(encodedKey)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	ciphertext, err := secrets.Encrypt(plaintext, key)
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stdout.Write(ciphertext)
+
+	return err
+}